@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"wallet/internal/config"
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/storage/postgresql"
+)
+
+// apikey создает клиента API и печатает его ключ. Ключ показывается один раз:
+// в базе остается только хеш.
+func main() {
+	var clientID, name, scope string
+
+	flag.StringVar(&clientID, "client-id", "", "client id, used as owner_id of client wallets")
+	flag.StringVar(&name, "name", "", "client name")
+	flag.StringVar(&scope, "scope", "read", "client scope: read, deposit, full or admin")
+	flag.Parse()
+
+	if clientID == "" {
+		fail("client-id is required")
+	}
+	if name == "" {
+		name = clientID
+	}
+	if !auth.IsValidScope(scope) {
+		fail(fmt.Sprintf("unknown scope %q", scope))
+	}
+
+	cfg := config.MustLoad()
+
+	storage, err := postgresql.NewStorage(cfg.Storage.URL(), postgresql.Timeouts{
+		Read:  cfg.Storage.ReadTimeout,
+		Write: cfg.Storage.WriteTimeout,
+	})
+	if err != nil {
+		fail(err.Error())
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		fail(err.Error())
+	}
+	key := hex.EncodeToString(buf)
+
+	client, err := storage.CreateAPIClient(context.Background(), postgresql.APIClient{
+		ClientID: clientID,
+		Name:     name,
+		Scope:    scope,
+	}, auth.HashKey(key))
+	if err != nil {
+		fail(err.Error())
+	}
+
+	fmt.Printf("client_id: %s\nscope: %s\napi_key: %s\n", client.ClientID, client.Scope, key)
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
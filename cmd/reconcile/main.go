@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wallet/internal/config"
+	"wallet/storage/postgresql"
+)
+
+// Коды выхода: 0 — расхождений нет, 1 — найдены расхождения (даже если
+// исправлены с --fix), 2 — сверка не выполнена.
+const (
+	exitMismatch = 1
+	exitFailure  = 2
+)
+
+type Mismatch struct {
+	WalletID         uuid.UUID `json:"wallet_id"`
+	Currency         string    `json:"currency"`
+	Balance          int64     `json:"balance"`
+	Computed         int64     `json:"computed"`
+	Difference       int64     `json:"difference"`
+	Ledger           int64     `json:"ledger"`
+	LedgerDifference int64     `json:"ledger_difference"`
+	Fixed            bool      `json:"fixed"`
+	Error            string    `json:"error,omitempty"`
+}
+
+type Report struct {
+	CheckedAt  time.Time  `json:"checked_at"`
+	Checked    int        `json:"wallets_checked"`
+	Fix        bool       `json:"fix"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// reconcile пересчитывает баланс каждого кошелька по журналу операций и по
+// его счету в двойной записи, сравнивает с wallets.balance и печатает отчет
+// в JSON. С --fix дописывает операции и проводки ADJUSTMENT на разницу;
+// wallets.balance не меняется.
+func main() {
+	var fix bool
+
+	flag.BoolVar(&fix, "fix", false, "write ADJUSTMENT operations and postings for found mismatches")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Сверка читает весь журнал, поэтому без дедлайна на чтение.
+	storage, err := postgresql.NewStorage(cfg.Storage.URL(), postgresql.Timeouts{
+		Write: cfg.Storage.WriteTimeout,
+	})
+	if err != nil {
+		fail(err.Error())
+	}
+
+	report := Report{CheckedAt: time.Now().UTC(), Fix: fix, Mismatches: []Mismatch{}}
+
+	found, checked, err := storage.FindBalanceMismatches(ctx)
+	if err != nil {
+		fail(err.Error())
+	}
+	report.Checked = checked
+
+	meta := postgresql.OperationMeta{RequestID: "reconcile-" + report.CheckedAt.Format("20060102T150405Z")}
+
+	failed := false
+	for _, m := range found {
+		res := Mismatch{
+			WalletID:         m.WalletID,
+			Currency:         m.Currency,
+			Balance:          m.Balance,
+			Computed:         m.Computed,
+			Difference:       m.Difference,
+			Ledger:           m.Ledger,
+			LedgerDifference: m.LedgerDifference,
+		}
+
+		if fix {
+			// Расхождение пересчитывается под блокировкой кошелька: в отчет
+			// попадает то, что реально записано.
+			fixed, err := storage.FixBalanceMismatch(ctx, m.WalletID, meta)
+			if err != nil {
+				res.Error = err.Error()
+				failed = true
+			} else {
+				res.Computed = fixed.Computed
+				res.Difference = fixed.Difference
+				res.Ledger = fixed.Ledger
+				res.LedgerDifference = fixed.LedgerDifference
+				res.Fixed = true
+			}
+		}
+
+		report.Mismatches = append(report.Mismatches, res)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fail(err.Error())
+	}
+
+	switch {
+	case failed:
+		os.Exit(exitFailure)
+	case len(report.Mismatches) > 0:
+		os.Exit(exitMismatch)
+	}
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(exitFailure)
+}
@@ -1,19 +1,49 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"wallet/internal/broadcast"
 	"wallet/internal/config"
+	"wallet/internal/fees"
+	"wallet/internal/health"
+	"wallet/internal/holds"
+	"wallet/internal/http-server/handlers/creator"
 	"wallet/internal/http-server/handlers/getter"
+	healthHandlers "wallet/internal/http-server/handlers/health"
+	"wallet/internal/http-server/handlers/history"
+	"wallet/internal/http-server/handlers/hold"
+	"wallet/internal/http-server/handlers/ledger"
+	"wallet/internal/http-server/handlers/limits"
+	"wallet/internal/http-server/handlers/lister"
+	"wallet/internal/http-server/handlers/status"
+	"wallet/internal/http-server/handlers/stream"
 	"wallet/internal/http-server/handlers/transaction"
+	"wallet/internal/http-server/handlers/transfer"
+	"wallet/internal/http-server/handlers/webhooks"
+	"wallet/internal/http-server/middleware/auth"
 	mwLogger "wallet/internal/http-server/middleware/logger"
+	mwMetrics "wallet/internal/http-server/middleware/metrics"
+	mwTracing "wallet/internal/http-server/middleware/tracing"
 	"wallet/internal/lib/logger/sl"
+	"wallet/internal/metrics"
+	"wallet/internal/rates"
+	"wallet/internal/tracing"
+	"wallet/internal/webhook"
 	"wallet/storage/postgresql"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
@@ -23,17 +53,19 @@ func main() {
 
 	log.Info("starting wallet", slog.String("env", cfg.Env))
 
-	var dbURL string = fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-		cfg.SSLMode,
-	)
-
-	storage, err := postgresql.NewStorage(dbURL)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Error("failed to setup tracing", sl.Err(err))
+		os.Exit(1)
+	}
+
+	storage, err := postgresql.NewStorage(cfg.Storage.URL(), postgresql.Timeouts{
+		Read:  cfg.Storage.ReadTimeout,
+		Write: cfg.Storage.WriteTimeout,
+	})
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
 		os.Exit(1)
@@ -41,35 +73,238 @@ func main() {
 
 	log.Info("Successfully connected to PostgreSQL")
 
+	schemaVersion, err := health.LatestMigration(cfg.Health.MigrationsPath)
+	if err != nil {
+		log.Error("failed to read migrations", sl.Err(err))
+		os.Exit(1)
+	}
+
+	converter, err := setupConverter(cfg.Rates)
+	if err != nil {
+		log.Error("failed to load exchange rates", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if cfg.Fees.Path != "" {
+		schedule, err := fees.LoadFile(cfg.Fees.Path)
+		if err != nil {
+			log.Error("failed to load fee rules", sl.Err(err))
+			os.Exit(1)
+		}
+		storage.SetFees(schedule)
+	}
+
+	authMiddleware, err := setupAuth(ctx, cfg.Auth, log, storage)
+	if err != nil {
+		log.Error("failed to setup auth", sl.Err(err))
+		os.Exit(1)
+	}
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterDBStats(storage.Stats)
+
+	hub := broadcast.NewHub(cfg.Stream.BufferSize)
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(mwTracing.New())
 	router.Use(mwLogger.New(log))
+	router.Use(mwMetrics.New(appMetrics))
 	router.Use(middleware.URLFormat)
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Recoverer)
 
+	readiness := &health.Readiness{}
+
+	router.Get("/healthz", healthHandlers.Healthz())
+	router.Handle("/metrics", promhttp.HandlerFor(appMetrics.Registry, promhttp.HandlerOpts{}))
+	router.Get("/readyz", healthHandlers.Readyz(log, readiness, storage, schemaVersion, cfg.Health.CheckTimeout))
+
+	router.Group(func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		r.With(auth.Require(auth.PermCreate)).Post("/api/v1/wallets", creator.CreateWallet(log, storage))
+		r.With(auth.Require(auth.PermRead)).Get("/api/v1/wallets", lister.ListWallets(log, storage))
+		r.With(auth.Require(auth.PermRead)).Get("/api/v1/wallets/{WALLET_UUID}", getter.FetchWallet(log, storage))
+		r.With(auth.Require(auth.PermRead)).Get("/api/v1/wallets/{WALLET_UUID}/stream", stream.BalanceStream(log, storage, hub, cfg.Stream.Heartbeat))
+		r.With(auth.Require(auth.PermRead)).Get("/api/v1/wallets/{WALLET_UUID}/operations", history.FetchOperations(log, storage))
+		r.With(auth.Require(auth.PermRead)).Get("/api/v1/wallets/{WALLET_UUID}/statement", history.FetchStatement(log, storage))
+		r.With(auth.Require(auth.PermWithdraw)).Post("/api/v1/wallets/{WALLET_UUID}/holds", hold.CreateHold(log, storage, cfg.Holds.DefaultTTL, cfg.Holds.MaxTTL))
+		r.With(auth.Require(auth.PermWithdraw)).Post("/api/v1/wallets/{WALLET_UUID}/holds/{HOLD_UUID}/capture", hold.CaptureHold(log, storage))
+		r.With(auth.Require(auth.PermWithdraw)).Post("/api/v1/wallets/{WALLET_UUID}/holds/{HOLD_UUID}/void", hold.VoidHold(log, storage))
+		r.Post("/api/v1/wallet", transaction.WalletOperation(log, metrics.InstrumentOperation(storage, appMetrics)))
+		r.Post("/api/v1/wallet/batch", transaction.WalletBatch(log, metrics.InstrumentBatch(storage, appMetrics), cfg.BatchMaxItems))
+		r.With(auth.Require(auth.PermTransfer)).Post("/api/v1/transfers", transfer.Transfer(log, storage, converter))
+
+		r.Route("/api/v1/admin", func(r chi.Router) {
+			r.Use(auth.Require(auth.PermAdmin))
+
+			r.Post("/wallets/{WALLET_UUID}/status", status.ChangeStatus(log, storage))
+			r.Get("/wallets/{WALLET_UUID}/status/history", status.FetchHistory(log, storage))
+			r.Get("/wallets/{WALLET_UUID}/limits", limits.FetchLimits(log, storage))
+			r.Put("/wallets/{WALLET_UUID}/limits", limits.UpdateLimits(log, storage))
+			r.Put("/wallets/{WALLET_UUID}/credit-limit", limits.UpdateCreditLimit(log, storage))
+			r.Put("/wallets/{WALLET_UUID}/tier", limits.UpdateTier(log, storage))
+
+			r.Get("/ledger/accounts", ledger.FetchAccounts(log, storage))
+			r.Get("/ledger/entries", ledger.FetchEntries(log, storage))
+
+			r.Post("/webhooks", webhooks.Create(log, storage))
+			r.Get("/webhooks", webhooks.List(log, storage))
+			r.Delete("/webhooks/{WEBHOOK_UUID}", webhooks.Deactivate(log, storage))
+			r.Get("/webhooks/{WEBHOOK_UUID}/deliveries", webhooks.FetchDeliveries(log, storage))
+			r.Post("/webhooks/{WEBHOOK_UUID}/deliveries/{DELIVERY_UUID}/retry", webhooks.RetryDelivery(log, storage))
+		})
+	})
+
+	var background sync.WaitGroup
 
-	router.Get("/api/v1/wallets/{WALLET_UUID}", getter.FetchWallet(log, storage))
-	router.Post("/api/v1/wallet", transaction.WalletOperation(log, storage))
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		holds.RunSweeper(ctx, log, storage, cfg.Holds.SweepInterval)
+	}()
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		webhook.NewDispatcher(log, storage, webhook.Config{
+			Interval:    cfg.Webhooks.DispatchInterval,
+			BatchSize:   cfg.Webhooks.BatchSize,
+			Workers:     cfg.Webhooks.Workers,
+			Timeout:     cfg.Webhooks.DeliveryTimeout,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			BackoffBase: cfg.Webhooks.BackoffBase,
+			BackoffMax:  cfg.Webhooks.BackoffMax,
+		}).Run(ctx)
+	}()
+
+	// Отмена ctx закрывает hub, и открытые SSE-потоки завершаются до
+	// остановки HTTP-сервера.
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		if err := broadcast.Listen(ctx, log, cfg.Storage.URL(), hub); err != nil {
+			log.Error("failed to listen for balance changes", sl.Err(err))
+		}
+	}()
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
-		Handler:      router,
+		Handler:      otelhttp.NewHandler(router, "http.server"),
 		ReadTimeout:  cfg.Timeout,
 		WriteTimeout: cfg.Timeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server")
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	readiness.SetReady(true)
+
+	select {
+	case <-ctx.Done():
+		log.Info("shutdown signal received")
+	case err := <-serverErr:
+		log.Error("failed to start server", sl.Err(err))
+	}
+	stop()
+
+	// Сначала /readyz начинает отвечать 503, и только потом сервер перестает
+	// принимать соединения: балансировщик успевает убрать инстанс.
+	readiness.SetReady(false)
+	log.Info("readiness disabled, draining", slog.String("delay", cfg.ShutdownDelay.String()))
+	time.Sleep(cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain connections", sl.Err(err))
+	} else {
+		log.Info("http server stopped")
 	}
 
-	log.Error("server stopped")
+	background.Wait()
+	log.Info("background workers stopped")
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+	} else {
+		log.Info("storage closed")
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
+
+	log.Info("server stopped")
+}
+
+const (
+	authNone   = "none"
+	authAPIKey = "apikey"
+	authJWT    = "jwt"
+)
+
+func setupAuth(ctx context.Context, cfg config.Auth, log *slog.Logger, storage *postgresql.StoragePostgresql) (func(http.Handler) http.Handler, error) {
+	switch cfg.Mode {
+	case authNone:
+		log.Warn("authentication is disabled")
+		return func(next http.Handler) http.Handler { return next }, nil
+	case authAPIKey:
+		return auth.New(log, storage), nil
+	case authJWT:
+		if cfg.JWKSPath == "" {
+			return nil, errors.New("AUTH_JWKS_PATH is required for jwt auth")
+		}
+		if !auth.IsValidScope(cfg.JWTDefaultScope) {
+			return nil, fmt.Errorf("unknown default scope %q", cfg.JWTDefaultScope)
+		}
+
+		keys, err := auth.LoadKeySet(cfg.JWKSPath)
+		if err != nil {
+			return nil, err
+		}
+		go keys.Watch(ctx, log, cfg.JWKSReloadInterval)
+
+		return auth.NewJWT(log, auth.NewVerifier(keys, auth.JWTConfig{
+			Audience:     cfg.JWTAudience,
+			Issuer:       cfg.JWTIssuer,
+			OwnerClaim:   cfg.JWTOwnerClaim,
+			ScopeClaim:   cfg.JWTScopeClaim,
+			DefaultScope: cfg.JWTDefaultScope,
+		})), nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", cfg.Mode)
+	}
+}
+
+func setupConverter(cfg config.Rates) (*rates.Converter, error) {
+	rounding, err := rates.ParseRounding(cfg.Rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider rates.RateProvider
+	if cfg.Path == "" {
+		provider, err = rates.NewStatic()
+	} else {
+		provider, err = rates.LoadFile(cfg.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	return rates.NewConverter(provider, rounding), nil
 }
 
 const (
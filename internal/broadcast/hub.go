@@ -0,0 +1,152 @@
+package broadcast
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"wallet/storage/postgresql"
+)
+
+var ErrClosed = errors.New("hub is closed")
+
+// Hub раздает события об изменении баланса подписчикам кошелька. Publish
+// никогда не блокируется: подписчик с переполненным буфером отключается,
+// чтобы медленный клиент не задерживал остальных.
+type Hub struct {
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription — события одного кошелька. Канал Events закрывается при
+// отписке, остановке хаба или переполнении буфера (тогда Lagged == true).
+type Subscription struct {
+	hub      *Hub
+	walletID uuid.UUID
+	events   chan postgresql.BalanceEvent
+	lagged   bool
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Hub{
+		bufferSize: bufferSize,
+		subs:       map[uuid.UUID]map[*Subscription]struct{}{},
+	}
+}
+
+func (h *Hub) Subscribe(walletID uuid.UUID) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &Subscription{
+		hub:      h,
+		walletID: walletID,
+		events:   make(chan postgresql.BalanceEvent, h.bufferSize),
+	}
+	if h.subs[walletID] == nil {
+		h.subs[walletID] = map[*Subscription]struct{}{}
+	}
+	h.subs[walletID][sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (h *Hub) Publish(event postgresql.BalanceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[event.WalletID] {
+		select {
+		case sub.events <- event:
+		default:
+			sub.lagged = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Reset отключает всех подписчиков как отставших: после переподключения к
+// базе часть уведомлений могла быть потеряна, и клиентам нужно заново
+// получить текущий баланс.
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subs := range h.subs {
+		for sub := range subs {
+			sub.lagged = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Close отключает всех подписчиков; новые подписки больше не принимаются.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribers — число активных подписок.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for _, subs := range h.subs {
+		n += len(subs)
+	}
+	return n
+}
+
+// remove вызывается под h.mu.
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subs[sub.walletID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.walletID)
+	}
+	close(sub.events)
+}
+
+func (s *Subscription) Events() <-chan postgresql.BalanceEvent {
+	return s.events
+}
+
+// Lagged сообщает, что подписка закрыта из-за отставания клиента. Имеет
+// смысл после закрытия канала Events.
+func (s *Subscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.lagged
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
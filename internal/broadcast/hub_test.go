@@ -0,0 +1,132 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wallet/storage/postgresql"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub(4)
+	walletID := uuid.New()
+
+	sub, err := hub.Subscribe(walletID)
+	require.NoError(t, err)
+	other, err := hub.Subscribe(uuid.New())
+	require.NoError(t, err)
+
+	hub.Publish(postgresql.BalanceEvent{WalletID: walletID, Balance: 100})
+
+	select {
+	case event := <-sub.Events():
+		assert.Equal(t, int64(100), event.Balance)
+	default:
+		t.Fatal("event was not delivered")
+	}
+	assert.Empty(t, other.Events())
+
+	sub.Close()
+	sub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.False(t, sub.Lagged())
+	assert.Equal(t, 1, hub.Subscribers())
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(2)
+	walletID := uuid.New()
+
+	slow, err := hub.Subscribe(walletID)
+	require.NoError(t, err)
+	fast, err := hub.Subscribe(walletID)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		hub.Publish(postgresql.BalanceEvent{WalletID: walletID, Balance: int64(i)})
+		if i < 3 {
+			<-fast.Events()
+		}
+	}
+
+	// Медленный подписчик получает то, что успело попасть в буфер, и
+	// закрытый канал.
+	var received []int64
+	for event := range slow.Events() {
+		received = append(received, event.Balance)
+	}
+	assert.Equal(t, []int64{1, 2}, received)
+	assert.True(t, slow.Lagged())
+
+	event := <-fast.Events()
+	assert.Equal(t, int64(3), event.Balance)
+	assert.False(t, fast.Lagged())
+	assert.Equal(t, 1, hub.Subscribers())
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(1)
+
+	sub, err := hub.Subscribe(uuid.New())
+	require.NoError(t, err)
+
+	hub.Close()
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.False(t, sub.Lagged())
+
+	_, err = hub.Subscribe(uuid.New())
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestConsume(t *testing.T) {
+	hub := NewHub(4)
+	walletID := uuid.New()
+
+	sub, err := hub.Subscribe(walletID)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(postgresql.BalanceEvent{WalletID: walletID, Balance: 42})
+	require.NoError(t, err)
+
+	notifications := make(chan *pq.Notification)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		consume(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), hub, notifications, func() error { return nil })
+		close(done)
+	}()
+
+	notifications <- &pq.Notification{Channel: postgresql.BalanceChannel, Extra: "not json"}
+	notifications <- &pq.Notification{Channel: postgresql.BalanceChannel, Extra: string(payload)}
+
+	select {
+	case event := <-sub.Events():
+		assert.Equal(t, int64(42), event.Balance)
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	// Переподключение к базе сбрасывает подписчиков.
+	notifications <- nil
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.True(t, sub.Lagged())
+
+	cancel()
+	<-done
+
+	_, err = hub.Subscribe(walletID)
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,94 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage/postgresql"
+)
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+
+	// pingInterval — как часто проверять соединение, если уведомлений нет.
+	pingInterval = 90 * time.Second
+)
+
+// Listen слушает postgresql.BalanceChannel и раздает события через hub,
+// пока не отменен ctx. При остановке закрывает hub, завершая все подписки.
+func Listen(ctx context.Context, log *slog.Logger, dbURL string, hub *Hub) error {
+	log = log.With(slog.String("component", "broadcast/listener"))
+
+	listener := pq.NewListener(dbURL, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			log.Warn("listener disconnected", sl.Err(err))
+		case pq.ListenerEventReconnected:
+			log.Info("listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Warn("listener reconnect failed", sl.Err(err))
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(postgresql.BalanceChannel); err != nil {
+		hub.Close()
+		return fmt.Errorf("listen %s: %w", postgresql.BalanceChannel, err)
+	}
+
+	consume(ctx, log, hub, listener.Notify, listener.Ping)
+
+	return nil
+}
+
+// consume раздает уведомления до отмены ctx. nil из notifications означает
+// переподключение к базе: пропущенные уведомления не восстановить, поэтому
+// подписчики сбрасываются.
+func consume(ctx context.Context, log *slog.Logger, hub *Hub, notifications <-chan *pq.Notification, ping func() error) {
+	defer hub.Close()
+
+	timer := time.NewTimer(pingInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-notifications:
+			if n == nil {
+				hub.Reset()
+				continue
+			}
+
+			var event postgresql.BalanceEvent
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				log.Error("failed to decode notification", sl.Err(err))
+				continue
+			}
+			hub.Publish(event)
+
+		case <-timer.C:
+			go func() {
+				if err := ping(); err != nil {
+					log.Warn("listener ping failed", sl.Err(err))
+				}
+			}()
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(pingInterval)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -12,12 +13,26 @@ type Config struct {
 	Env string `env:"ENV" env-default:"local" env-required:"true"`
 	Storage
 	HTTPServer
+	Rates
+	Fees
+	Holds
+	Auth
+	Health
+	Tracing
+	Webhooks
+	Stream
 }
 
 type HTTPServer struct {
 	Address     string        `env:"SERVER_ADDRESS" env-required:"true"`
 	Timeout     time.Duration `env:"SERVER_TIMEOUT" env-required:"true"`
 	IdleTimeout time.Duration `env:"SERVER_IDLE_TIMEOUT" env-required:"true"`
+	// ShutdownDelay — пауза между снятием готовности и остановкой сервера,
+	// ShutdownTimeout — сколько ждать завершения текущих запросов.
+	ShutdownDelay   time.Duration `env:"SERVER_SHUTDOWN_DELAY" env-default:"0s"`
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" env-default:"15s"`
+	// BatchMaxItems — максимальное число операций в одном запросе /api/v1/wallet/batch.
+	BatchMaxItems int `env:"BATCH_MAX_ITEMS" env-default:"1000"`
 }
 
 type Storage struct {
@@ -27,6 +42,95 @@ type Storage struct {
 	User     string `env:"DB_USER" env-required:"true"`
 	Password string `env:"DB_PASS" env-required:"true"`
 	SSLMode  string `env:"DB_SSL_MODE" env-default:"disable"`
+
+	// Дедлайны запросов: ReadTimeout — для выборок, WriteTimeout — для
+	// транзакций с изменениями.
+	ReadTimeout  time.Duration `env:"DB_READ_TIMEOUT" env-default:"2s"`
+	WriteTimeout time.Duration `env:"DB_WRITE_TIMEOUT" env-default:"5s"`
+}
+
+func (s Storage) URL() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		s.User,
+		s.Password,
+		s.Host,
+		s.Port,
+		s.DBName,
+		s.SSLMode,
+	)
+}
+
+// Rates — курсы для переводов между кошельками в разных валютах. Без Path
+// доступны только переводы в одной валюте.
+type Rates struct {
+	Path     string `env:"RATES_PATH"`
+	Rounding string `env:"RATES_ROUNDING" env-default:"half_even"`
+}
+
+// Fees — правила комиссий (YAML, см. fees.LoadFile). Без Path комиссии не
+// берутся.
+type Fees struct {
+	Path string `env:"FEES_PATH"`
+}
+
+type Holds struct {
+	DefaultTTL    time.Duration `env:"HOLDS_DEFAULT_TTL" env-default:"15m"`
+	MaxTTL        time.Duration `env:"HOLDS_MAX_TTL" env-default:"168h"`
+	SweepInterval time.Duration `env:"HOLDS_SWEEP_INTERVAL" env-default:"30s"`
+}
+
+// Auth — AUTH_MODE: none (доступ без проверки), apikey (ключ клиента
+// в заголовке X-API-Key, клиенты в таблице api_clients) или jwt (токен
+// в Authorization: Bearer, ключи проверки в JWKS-файле).
+type Auth struct {
+	Mode string `env:"AUTH_MODE" env-default:"none"`
+
+	JWKSPath           string        `env:"AUTH_JWKS_PATH"`
+	JWKSReloadInterval time.Duration `env:"AUTH_JWKS_RELOAD_INTERVAL" env-default:"30s"`
+	JWTAudience        string        `env:"AUTH_JWT_AUDIENCE"`
+	JWTIssuer          string        `env:"AUTH_JWT_ISSUER"`
+	JWTOwnerClaim      string        `env:"AUTH_JWT_OWNER_CLAIM" env-default:"sub"`
+	JWTScopeClaim      string        `env:"AUTH_JWT_SCOPE_CLAIM" env-default:"scope"`
+	JWTDefaultScope    string        `env:"AUTH_JWT_DEFAULT_SCOPE" env-default:"read"`
+}
+
+// Health — проверки /readyz: таймаут на все зависимости и каталог
+// миграций, по которому определяется ожидаемая версия схемы.
+type Health struct {
+	CheckTimeout   time.Duration `env:"HEALTH_CHECK_TIMEOUT" env-default:"1s"`
+	MigrationsPath string        `env:"MIGRATIONS_PATH" env-default:"./migrations"`
+}
+
+// Tracing — TRACING_EXPORTER: none, otlp (OTLP/HTTP на TRACING_OTLP_ENDPOINT),
+// stdout или file (TRACING_FILE).
+type Tracing struct {
+	Exporter     string  `env:"TRACING_EXPORTER" env-default:"none"`
+	OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4318"`
+	OTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" env-default:"true"`
+	File         string  `env:"TRACING_FILE" env-default:"./traces.json"`
+	ServiceName  string  `env:"TRACING_SERVICE_NAME" env-default:"wallet"`
+	SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// Webhooks — доставка событий из outbox. После WEBHOOK_MAX_ATTEMPTS
+// неудачных попыток доставка переводится в DEAD; паузы между попытками
+// растут от WEBHOOK_BACKOFF_BASE вдвое, но не больше WEBHOOK_BACKOFF_MAX.
+type Webhooks struct {
+	DispatchInterval time.Duration `env:"WEBHOOK_DISPATCH_INTERVAL" env-default:"1s"`
+	BatchSize        int           `env:"WEBHOOK_BATCH_SIZE" env-default:"100"`
+	Workers          int           `env:"WEBHOOK_WORKERS" env-default:"4"`
+	DeliveryTimeout  time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"5s"`
+	MaxAttempts      int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"10"`
+	BackoffBase      time.Duration `env:"WEBHOOK_BACKOFF_BASE" env-default:"5s"`
+	BackoffMax       time.Duration `env:"WEBHOOK_BACKOFF_MAX" env-default:"1h"`
+}
+
+// Stream — SSE-подписки на баланс. Подписчик, у которого накопилось больше
+// STREAM_BUFFER_SIZE неотправленных событий, отключается.
+type Stream struct {
+	BufferSize int           `env:"STREAM_BUFFER_SIZE" env-default:"32"`
+	Heartbeat  time.Duration `env:"STREAM_HEARTBEAT" env-default:"15s"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,200 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"wallet/internal/lib/currency"
+)
+
+// Операции, для которых задаются комиссии.
+const (
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+	OperationTransfer = "TRANSFER"
+)
+
+// DefaultTier — правило для кошельков, у тарифа которых нет своего правила.
+const DefaultTier = "default"
+
+var ErrInvalidRule = errors.New("invalid fee rule")
+
+// Rule — комиссия за Operation для кошельков тарифа Tier (пусто —
+// DefaultTier). Непустой Currency ограничивает правило кошельками в этой
+// валюте; правило без валюты действует для остальных.
+//
+// Комиссия = Fixed + Percent процентов от суммы, дробная часть округляется
+// вверх, затем результат ограничивается снизу Min и сверху Max (0 — без
+// ограничения). Fixed, Min и Max — в минимальных единицах валюты кошелька.
+type Rule struct {
+	Operation string `yaml:"operation"`
+	Tier      string `yaml:"tier"`
+	Currency  string `yaml:"currency"`
+	Fixed     int64  `yaml:"fixed"`
+	Percent   string `yaml:"percent"`
+	Min       int64  `yaml:"min"`
+	Max       int64  `yaml:"max"`
+
+	percent *big.Rat
+}
+
+// Schedule — набор правил. Нулевой или nil Schedule комиссий не берет.
+type Schedule struct {
+	rules map[string]Rule
+}
+
+type file struct {
+	Fees []Rule `yaml:"fees"`
+}
+
+func NewSchedule(rules ...Rule) (*Schedule, error) {
+	s := &Schedule{rules: make(map[string]Rule, len(rules))}
+
+	for _, r := range rules {
+		r.Operation = strings.ToUpper(r.Operation)
+		r.Currency = strings.ToUpper(r.Currency)
+		if r.Tier == "" {
+			r.Tier = DefaultTier
+		}
+
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("fee %s/%s: %w", r.Operation, r.Tier, err)
+		}
+
+		key := ruleKey(r.Operation, r.Tier, r.Currency)
+		if _, ok := s.rules[key]; ok {
+			return nil, fmt.Errorf("fee %s/%s: duplicate rule: %w", r.Operation, r.Tier, ErrInvalidRule)
+		}
+		s.rules[key] = r
+	}
+
+	return s, nil
+}
+
+// LoadFile читает правила из YAML:
+//
+//	fees:
+//	  - operation: WITHDRAW
+//	    fixed: 30
+//	    percent: "1.5"
+//	    min: 50
+//	    max: 1000
+//	  - operation: WITHDRAW
+//	    tier: premium
+//	    percent: "0.5"
+func LoadFile(path string) (*Schedule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fees file: %w", err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse fees file: %w", err)
+	}
+
+	return NewSchedule(f.Fees...)
+}
+
+// Fee — комиссия за операцию на amount для кошелька тарифа tier в валюте
+// currency. Правило ищется в порядке: тариф и валюта, тариф, DefaultTier и
+// валюта, DefaultTier. Нет правила — комиссии нет.
+func (s *Schedule) Fee(operation, tier, currency string, amount int64) int64 {
+	r, ok := s.rule(operation, tier, currency)
+	if !ok {
+		return 0
+	}
+	return r.fee(amount)
+}
+
+func (s *Schedule) rule(operation, tier, currency string) (Rule, bool) {
+	if s == nil || len(s.rules) == 0 {
+		return Rule{}, false
+	}
+
+	tiers := []string{tier, DefaultTier}
+	if tier == "" || tier == DefaultTier {
+		tiers = tiers[1:]
+	}
+
+	for _, t := range tiers {
+		if r, ok := s.rules[ruleKey(operation, t, currency)]; ok {
+			return r, true
+		}
+		if r, ok := s.rules[ruleKey(operation, t, "")]; ok {
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+func (r Rule) fee(amount int64) int64 {
+	fee := new(big.Int).SetInt64(r.Fixed)
+
+	if r.percent != nil && amount > 0 {
+		part := new(big.Rat).Mul(r.percent, new(big.Rat).SetInt64(amount))
+		part.Quo(part, big.NewRat(100, 1))
+		fee.Add(fee, ceil(part))
+	}
+
+	if fee.Cmp(big.NewInt(r.Min)) < 0 {
+		return r.Min
+	}
+	if r.Max > 0 && fee.Cmp(big.NewInt(r.Max)) > 0 {
+		return r.Max
+	}
+	if !fee.IsInt64() {
+		// Такую комиссию все равно не списать ни с одного кошелька.
+		return math.MaxInt64
+	}
+	return fee.Int64()
+}
+
+func (r *Rule) validate() error {
+	switch r.Operation {
+	case OperationDeposit, OperationWithdraw, OperationTransfer:
+	default:
+		return fmt.Errorf("unknown operation %q: %w", r.Operation, ErrInvalidRule)
+	}
+
+	if r.Currency != "" && !currency.IsSupported(r.Currency) {
+		return fmt.Errorf("unsupported currency %q: %w", r.Currency, ErrInvalidRule)
+	}
+
+	if r.Fixed < 0 || r.Min < 0 || r.Max < 0 {
+		return fmt.Errorf("fixed, min and max must not be negative: %w", ErrInvalidRule)
+	}
+	if r.Max > 0 && r.Min > r.Max {
+		return fmt.Errorf("min %d exceeds max %d: %w", r.Min, r.Max, ErrInvalidRule)
+	}
+
+	if r.Percent != "" {
+		p, ok := new(big.Rat).SetString(r.Percent)
+		if !ok || p.Sign() < 0 || p.Cmp(big.NewRat(100, 1)) > 0 {
+			return fmt.Errorf("percent must be between 0 and 100, got %q: %w", r.Percent, ErrInvalidRule)
+		}
+		r.percent = p
+	}
+
+	return nil
+}
+
+// ceil округляет неотрицательное значение вверх.
+func ceil(v *big.Rat) *big.Int {
+	q, rem := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if rem.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+func ruleKey(operation, tier, currency string) string {
+	return operation + "/" + tier + "/" + currency
+}
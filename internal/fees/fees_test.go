@@ -0,0 +1,98 @@
+package fees
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFee(t *testing.T) {
+	schedule, err := NewSchedule(
+		Rule{Operation: OperationWithdraw, Fixed: 30, Percent: "1.5", Min: 50, Max: 1000},
+		Rule{Operation: OperationWithdraw, Tier: "premium", Percent: "0.5"},
+		Rule{Operation: OperationWithdraw, Tier: "premium", Currency: "JPY", Fixed: 10},
+		Rule{Operation: OperationTransfer, Currency: "EUR", Fixed: 25},
+		Rule{Operation: OperationDeposit, Tier: "partner"},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		operation string
+		tier      string
+		currency  string
+		amount    int64
+		expected  int64
+	}{
+		{name: "fixed plus percent", operation: OperationWithdraw, currency: "USD", amount: 10000, expected: 180},
+		{name: "percent rounds up", operation: OperationWithdraw, currency: "USD", amount: 10001, expected: 181},
+		{name: "min", operation: OperationWithdraw, currency: "USD", amount: 100, expected: 50},
+		{name: "max", operation: OperationWithdraw, currency: "USD", amount: 1_000_000, expected: 1000},
+		{name: "unknown tier falls back to default", operation: OperationWithdraw, tier: "gold", currency: "USD", amount: 10000, expected: 180},
+		{name: "tier rule", operation: OperationWithdraw, tier: "premium", currency: "USD", amount: 10000, expected: 50},
+		{name: "tier and currency rule", operation: OperationWithdraw, tier: "premium", currency: "JPY", amount: 10000, expected: 10},
+		{name: "currency rule", operation: OperationTransfer, currency: "EUR", amount: 10000, expected: 25},
+		{name: "no rule for other currency", operation: OperationTransfer, currency: "USD", amount: 10000},
+		{name: "no rule for operation", operation: OperationDeposit, currency: "USD", amount: 10000},
+		{name: "empty tier rule waives fee", operation: OperationDeposit, tier: "partner", currency: "USD", amount: 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, schedule.Fee(tt.operation, tt.tier, tt.currency, tt.amount))
+		})
+	}
+}
+
+func TestFeeNilSchedule(t *testing.T) {
+	var schedule *Schedule
+	assert.Zero(t, schedule.Fee(OperationWithdraw, DefaultTier, "USD", 10000))
+}
+
+func TestNewScheduleInvalid(t *testing.T) {
+	rules := []Rule{
+		{Operation: "REFUND", Fixed: 10},
+		{Operation: OperationWithdraw, Fixed: -1},
+		{Operation: OperationWithdraw, Min: 100, Max: 50},
+		{Operation: OperationWithdraw, Percent: "101"},
+		{Operation: OperationWithdraw, Percent: "1,5"},
+		{Operation: OperationWithdraw, Currency: "XAU", Fixed: 10},
+	}
+
+	for _, r := range rules {
+		_, err := NewSchedule(r)
+		assert.ErrorIs(t, err, ErrInvalidRule, "%+v", r)
+	}
+
+	_, err := NewSchedule(
+		Rule{Operation: OperationWithdraw, Fixed: 10},
+		Rule{Operation: "withdraw", Tier: DefaultTier, Fixed: 20},
+	)
+	assert.ErrorIs(t, err, ErrInvalidRule)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+fees:
+  - operation: WITHDRAW
+    fixed: 30
+    percent: 1.5
+    min: 50
+  - operation: WITHDRAW
+    tier: premium
+    percent: "0.5"
+`), 0o600))
+
+	schedule, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(180), schedule.Fee(OperationWithdraw, "", "USD", 10000))
+	assert.Equal(t, int64(50), schedule.Fee(OperationWithdraw, "premium", "USD", 10000))
+
+	require.NoError(t, os.WriteFile(path, []byte("fees:\n  - {operation: WITHDRAW, percent: \"-1\"}\n"), 0o600))
+	_, err = LoadFile(path)
+	assert.ErrorIs(t, err, ErrInvalidRule)
+}
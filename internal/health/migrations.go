@@ -0,0 +1,43 @@
+package health
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LatestMigration возвращает номер последней миграции в каталоге dir
+// (файлы вида N_name.up.sql). С этой версией сверяется schema_migrations.
+func LatestMigration(dir string) (int64, error) {
+	const fn = "health.LatestMigration"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("%s: no migrations in %s", fn, dir)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,24 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"1_init.up.sql", "1_init.down.sql", "2_owner.up.sql", "10_holds.up.sql", "10_holds.down.sql", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	version, err := LatestMigration(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), version)
+
+	_, err = LatestMigration(t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+package health
+
+import "sync/atomic"
+
+// Readiness — готовность принимать трафик. Выставляется после старта
+// сервера и снимается перед остановкой, чтобы балансировщик успел увести
+// запросы до того, как сервер перестанет их принимать.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
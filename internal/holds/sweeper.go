@@ -0,0 +1,46 @@
+package holds
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"wallet/internal/lib/logger/sl"
+)
+
+const batchSize = 100
+
+type Expirer interface {
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+}
+
+// RunSweeper периодически снимает просроченные холды, пока не отменен ctx.
+func RunSweeper(ctx context.Context, log *slog.Logger, expirer Expirer, interval time.Duration) {
+	log = log.With(slog.String("component", "holds/sweeper"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Пачками, пока есть что снимать.
+		for {
+			n, err := expirer.ExpireHolds(ctx, batchSize)
+			if err != nil {
+				log.Error("failed to expire holds", sl.Err(err))
+				break
+			}
+			if n > 0 {
+				log.Info("holds expired", slog.Int("count", n))
+			}
+			if n < batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package creator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/currency"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+type WalletCreator interface {
+	CreateWallet(ctx context.Context, wallet postgresql.Wallet, meta postgresql.OperationMeta) (postgresql.Wallet, error)
+}
+
+// Request — обязательна только валюта; без wallet_id идентификатор генерирует
+// база. Balance задается в минимальных единицах валюты и требует прав
+// администратора.
+type Request struct {
+	WalletID uuid.UUID `json:"wallet_id"`
+	Balance  int64     `json:"balance" validate:"min=0"`
+	Currency string    `json:"currency" validate:"required,iso4217"`
+	OwnerID  string    `json:"owner_id" validate:"max=255"`
+}
+
+func CreateWallet(log *slog.Logger, walletCreator WalletCreator) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.creator.CreateWallet"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		if !currency.IsSupported(req.Currency) {
+			sender.SendError(w, r, log, http.StatusBadRequest, "unsupported currency", errors.New("unsupported currency "+req.Currency))
+			return
+		}
+
+		// Клиент создает кошельки только на себя.
+		if owner := auth.OwnerScope(r.Context()); owner != "" {
+			if req.OwnerID != "" && req.OwnerID != owner {
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", errors.New("owner_id belongs to another client"))
+				return
+			}
+			req.OwnerID = owner
+		}
+
+		// Начальный баланс минует права на пополнение, лимиты и комиссии: его
+		// задает только администратор (например, при переносе кошельков), а
+		// клиент пополняет кошелек отдельной операцией.
+		if req.Balance > 0 {
+			if err := auth.Check(r.Context(), auth.PermAdmin); err != nil {
+				sender.SendError(w, r, log, http.StatusForbidden, "opening balance requires admin scope", err)
+				return
+			}
+		}
+
+		meta := postgresql.OperationMeta{RequestID: middleware.GetReqID(r.Context())}
+
+		wallet, err := walletCreator.CreateWallet(r.Context(), postgresql.Wallet{
+			WalletID: req.WalletID,
+			Balance:  req.Balance,
+			Currency: req.Currency,
+			OwnerID:  req.OwnerID,
+		}, meta)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			if errors.Is(err, storage.ErrWalletExists) {
+				sender.SendError(w, r, log, http.StatusConflict, "wallet already exists", err)
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to create wallet", err)
+			return
+		}
+
+		log.Info("wallet created", slog.String("walletID", wallet.WalletID.String()))
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, getter.NewResponse(wallet))
+	}
+}
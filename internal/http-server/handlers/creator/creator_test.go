@@ -0,0 +1,172 @@
+package creator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWalletCreator struct {
+	mock.Mock
+}
+
+func (m *MockWalletCreator) CreateWallet(ctx context.Context, wallet postgresql.Wallet, meta postgresql.OperationMeta) (postgresql.Wallet, error) {
+	args := m.Called(wallet, meta)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+func TestCreateWallet(t *testing.T) {
+	generatedID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	clientID := uuid.MustParse("a45c73fd-3e36-466a-8e57-15e1cf0f35d2")
+
+	tests := []struct {
+		name           string
+		body           string
+		mockInput      postgresql.Wallet
+		mockWallet     postgresql.Wallet
+		mockErr        error
+		skipMock       bool
+		expectedStatus int
+		expectedResp   response.Response
+	}{
+		{
+			name:           "only currency",
+			body:           `{"currency":"EUR"}`,
+			mockInput:      postgresql.Wallet{Currency: "EUR"},
+			mockWallet:     postgresql.Wallet{WalletID: generatedID, Currency: "EUR"},
+			expectedStatus: http.StatusCreated,
+			expectedResp:   response.Response{Status: response.StatusOK},
+		},
+		{
+			name:           "client supplied id with balance and owner",
+			body:           `{"wallet_id":"a45c73fd-3e36-466a-8e57-15e1cf0f35d2","balance":250,"currency":"USD","owner_id":"client-1"}`,
+			mockInput:      postgresql.Wallet{WalletID: clientID, Balance: 250, Currency: "USD", OwnerID: "client-1"},
+			mockWallet:     postgresql.Wallet{WalletID: clientID, Balance: 250, Currency: "USD", OwnerID: "client-1"},
+			expectedStatus: http.StatusCreated,
+			expectedResp:   response.Response{Status: response.StatusOK},
+		},
+		{
+			name:           "wallet already exists",
+			body:           `{"wallet_id":"a45c73fd-3e36-466a-8e57-15e1cf0f35d2","currency":"USD"}`,
+			mockInput:      postgresql.Wallet{WalletID: clientID, Currency: "USD"},
+			mockErr:        storage.ErrWalletExists,
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet already exists"},
+		},
+		{
+			name:           "negative balance",
+			body:           `{"balance":-1,"currency":"USD"}`,
+			skipMock:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "field Balance is not valid"},
+		},
+		{
+			name:           "missing currency",
+			body:           "",
+			skipMock:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "field Currency is a required field"},
+		},
+		{
+			name:           "currency without known exponent",
+			body:           `{"currency":"XAU"}`,
+			skipMock:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "unsupported currency"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCreator := new(MockWalletCreator)
+			if !tt.skipMock {
+				mockCreator.On("CreateWallet", tt.mockInput, mock.Anything).Return(tt.mockWallet, tt.mockErr)
+			}
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/wallets", CreateWallet(slog.Default(), mockCreator))
+
+			req := httptest.NewRequest("POST", "/api/v1/wallets", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			fmt.Printf("Sending request: %s\n", req.URL)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res getter.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tt.expectedResp, res.Response)
+			if tt.mockErr == nil && !tt.skipMock {
+				assert.Equal(t, tt.mockWallet.WalletID, res.WalletID)
+				assert.Equal(t, tt.mockWallet.Balance, res.Balance)
+				assert.Equal(t, tt.mockWallet.Currency, res.Currency)
+				assert.Equal(t, tt.mockWallet.OwnerID, res.OwnerID)
+			}
+
+			mockCreator.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateWalletOpeningBalanceScope(t *testing.T) {
+	body := `{"balance":250,"currency":"USD"}`
+
+	tests := []struct {
+		name           string
+		scope          string
+		expectedStatus int
+	}{
+		{name: "full scope", scope: "full", expectedStatus: http.StatusForbidden},
+		{name: "admin scope", scope: "admin", expectedStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := auth.NewPrincipal("client-1", tt.scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mockCreator := new(MockWalletCreator)
+			if tt.expectedStatus == http.StatusCreated {
+				mockCreator.On("CreateWallet", postgresql.Wallet{Balance: 250, Currency: "USD"}, mock.Anything).
+					Return(postgresql.Wallet{WalletID: uuid.New(), Balance: 250, Currency: "USD"}, nil)
+			}
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/wallets", CreateWallet(slog.Default(), mockCreator))
+
+			req := httptest.NewRequest("POST", "/api/v1/wallets", bytes.NewBufferString(body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockCreator.AssertExpectations(t)
+		})
+	}
+}
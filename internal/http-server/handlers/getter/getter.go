@@ -1,23 +1,29 @@
 package getter
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
 
+	"wallet/internal/http-server/middleware/auth"
 	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/currency"
 	"wallet/internal/lib/logger/sl"
 	"wallet/storage"
 	"wallet/storage/postgresql"
 )
 
 type GetterWallet interface {
-	GetWallet(wallet_uuid uuid.UUID) (postgresql.Wallet, error)
+	GetWallet(ctx context.Context, wallet_uuid uuid.UUID) (postgresql.Wallet, error)
+	GetBalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time, ownerID string) (postgresql.BalanceAt, error)
 }
 
 
@@ -28,8 +34,43 @@ type Request struct {
 
 type Response struct {
 	resp.Response
-	WalletID uuid.UUID  `json:"wallet_id"`
-	Balance  int 		`json:"balance"`
+	WalletID    uuid.UUID `json:"wallet_id"`
+	Balance     int64     `json:"balance"`
+	Available   int64     `json:"available"`
+	CreditLimit int64     `json:"credit_limit"`
+	Currency    string    `json:"currency"`
+	Amount      string    `json:"amount"`
+	OwnerID     string    `json:"owner_id,omitempty"`
+	Status      string    `json:"wallet_status"`
+	Tier        string    `json:"tier,omitempty"`
+}
+
+// BalanceAtResponse — баланс на момент at по журналу операций. Холды и
+// кредитная линия в журнал не пишутся, поэтому available здесь нет.
+type BalanceAtResponse struct {
+	resp.Response
+	WalletID        uuid.UUID  `json:"wallet_id"`
+	Balance         int64      `json:"balance"`
+	Currency        string     `json:"currency"`
+	Amount          string     `json:"amount"`
+	At              time.Time  `json:"at"`
+	LastOperationID *uuid.UUID `json:"last_operation_id,omitempty"`
+	LastOperationAt *time.Time `json:"last_operation_at,omitempty"`
+}
+
+func NewResponse(wallet postgresql.Wallet) Response {
+	return Response{
+		Response:    resp.OK(),
+		WalletID:    wallet.WalletID,
+		Balance:     wallet.Balance,
+		Available:   wallet.Available(),
+		CreditLimit: wallet.CreditLimit,
+		Currency:    wallet.Currency,
+		Amount:      currency.Format(wallet.Balance, wallet.Currency),
+		OwnerID:     wallet.OwnerID,
+		Status:      wallet.Status,
+		Tier:        wallet.Tier,
+	}
 }
 
 func FetchWallet(log *slog.Logger, getterWallet GetterWallet) http.HandlerFunc {
@@ -61,7 +102,16 @@ func FetchWallet(log *slog.Logger, getterWallet GetterWallet) http.HandlerFunc {
 			return
 		}
 
-		resWallet, err := getterWallet.GetWallet(walletUUID)
+		if at := r.URL.Query().Get("at"); at != "" {
+			fetchBalanceAt(w, r, log, getterWallet, walletUUID, at)
+			return
+		}
+
+		resWallet, err := getterWallet.GetWallet(r.Context(), walletUUID)
+
+		if sender.SendContextError(w, r, log, err) {
+			return
+		}
 
 		if errors.Is(err, storage.ErrWalletNotFound) {
 			log.Error("does not exist", sl.Err(err), slog.String("wallet_uuid", walletUUIDStr))
@@ -77,10 +127,53 @@ func FetchWallet(log *slog.Logger, getterWallet GetterWallet) http.HandlerFunc {
 			return
 		}
 
-		render.JSON(w, r, Response{
-			Response: resp.OK(),
-			WalletID: resWallet.WalletID,
-			Balance: resWallet.Balance,
-		})
+		if owner := auth.OwnerScope(r.Context()); owner != "" && resWallet.OwnerID != owner {
+			log.Warn("access denied", slog.String("wallet_uuid", walletUUIDStr))
+			w.WriteHeader(http.StatusForbidden)
+			render.JSON(w, r, resp.Error("access denied"))
+			return
+		}
+
+		render.JSON(w, r, NewResponse(resWallet))
+	}
+}
+
+// fetchBalanceAt отвечает на GET /api/v1/wallets/{WALLET_UUID}?at=<RFC3339>.
+func fetchBalanceAt(w http.ResponseWriter, r *http.Request, log *slog.Logger, getterWallet GetterWallet, walletID uuid.UUID, value string) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		sender.SendError(w, r, log, http.StatusBadRequest, "at must be RFC3339", err)
+		return
+	}
+
+	balance, err := getterWallet.GetBalanceAt(r.Context(), walletID, at, auth.OwnerScope(r.Context()))
+	if err != nil {
+		if sender.SendContextError(w, r, log, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, storage.ErrWalletNotFound):
+			sender.SendError(w, r, log, http.StatusNotFound, "wallet does not exist", err)
+		case errors.Is(err, storage.ErrForbidden):
+			sender.SendError(w, r, log, http.StatusForbidden, "access denied", err)
+		default:
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to fetch balance", err)
+		}
+		return
+	}
+
+	res := BalanceAtResponse{
+		Response: resp.OK(),
+		WalletID: balance.WalletID,
+		Balance:  balance.Balance,
+		Currency: balance.Currency,
+		Amount:   currency.Format(balance.Balance, balance.Currency),
+		At:       balance.At,
 	}
-}
\ No newline at end of file
+	if op := balance.LastOperation; op != nil {
+		res.LastOperationID = &op.OperationID
+		res.LastOperationAt = &op.CreatedAt
+	}
+
+	render.JSON(w, r, res)
+}
@@ -1,6 +1,7 @@
 package getter
 
 import (
+	"context"
 	"testing"
 	"net/http"
 	"net/http/httptest"
@@ -12,17 +13,25 @@ import (
 	"wallet/storage/postgresql"
 	"wallet/internal/lib/api/response"
 	"fmt"
+	"time"
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/storage"
 )
 
 type MockGetterWallet struct {
 	mock.Mock
 }
 
-func (m *MockGetterWallet) GetWallet(wallet_uuid uuid.UUID) (postgresql.Wallet, error) {
+func (m *MockGetterWallet) GetWallet(ctx context.Context, wallet_uuid uuid.UUID) (postgresql.Wallet, error) {
 	args := m.Called(wallet_uuid)
 	return args.Get(0).(postgresql.Wallet), args.Error(1)
 }
 
+func (m *MockGetterWallet) GetBalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time, ownerID string) (postgresql.BalanceAt, error) {
+	args := m.Called(walletID, at, ownerID)
+	return args.Get(0).(postgresql.BalanceAt), args.Error(1)
+}
+
 func TestFetchWallet(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -175,3 +184,131 @@ func TestFetchWalletOne(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchWalletCreditLine(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	mockGetterWallet := new(MockGetterWallet)
+	mockGetterWallet.On("GetWallet", walletID).Return(postgresql.Wallet{
+		WalletID:    walletID,
+		Balance:     -2500,
+		Held:        500,
+		CreditLimit: 10000,
+		Currency:    "USD",
+		Status:      postgresql.WalletActive,
+	}, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/wallets/{WALLET_UUID}", FetchWallet(nil, mockGetterWallet))
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/"+walletID.String(), nil)
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res Response
+	if err := render.DecodeJSON(rec.Body, &res); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, int64(-2500), res.Balance)
+	assert.Equal(t, int64(10000), res.CreditLimit)
+	assert.Equal(t, int64(7000), res.Available)
+	assert.Equal(t, "-25.00", res.Amount)
+}
+
+func TestFetchWalletBalanceAt(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	operationID := uuid.MustParse("0b0e3a34-5f5c-4f63-9a4e-3c5a8d1e2f70")
+	monthEnd := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	operationAt := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+
+	partner, err := auth.NewPrincipal("partner", "read")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		query          string
+		principal      *auth.Principal
+		mockSetup      func(m *MockGetterWallet)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "balance at month end",
+			query: "?at=2024-01-31T23:59:59Z",
+			mockSetup: func(m *MockGetterWallet) {
+				m.On("GetBalanceAt", walletID, monthEnd, "").Return(postgresql.BalanceAt{
+					WalletID:      walletID,
+					Balance:       12345,
+					Currency:      "USD",
+					At:            monthEnd,
+					LastOperation: &postgresql.Operation{OperationID: operationID, CreatedAt: operationAt},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid time",
+			query:          "?at=yesterday",
+			mockSetup:      func(m *MockGetterWallet) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "at must be RFC3339",
+		},
+		{
+			name:      "wallet of another owner",
+			query:     "?at=2024-01-31T23:59:59Z",
+			principal: &partner,
+			mockSetup: func(m *MockGetterWallet) {
+				m.On("GetBalanceAt", walletID, monthEnd, "partner").Return(postgresql.BalanceAt{}, storage.ErrForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "access denied",
+		},
+		{
+			name:  "wallet not found",
+			query: "?at=2024-01-31T23:59:59Z",
+			mockSetup: func(m *MockGetterWallet) {
+				m.On("GetBalanceAt", walletID, monthEnd, "").Return(postgresql.BalanceAt{}, storage.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "wallet does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGetterWallet := new(MockGetterWallet)
+			tt.mockSetup(mockGetterWallet)
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/wallets/{WALLET_UUID}", FetchWallet(nil, mockGetterWallet))
+
+			req := httptest.NewRequest("GET", "/api/v1/wallets/"+walletID.String()+tt.query, nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), *tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res BalanceAtResponse
+			if err := render.DecodeJSON(rec.Body, &res); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			assert.Equal(t, tt.expectedError, res.Error)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, int64(12345), res.Balance)
+				assert.Equal(t, "123.45", res.Amount)
+				assert.True(t, monthEnd.Equal(res.At))
+				assert.Equal(t, &operationID, res.LastOperationID)
+			}
+			mockGetterWallet.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"wallet/internal/health"
+	resp "wallet/internal/lib/api/response"
+)
+
+type Dependencies interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int64, bool, error)
+}
+
+const (
+	checkOK   = "OK"
+	checkFail = "FAIL"
+)
+
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Checks map[string]Check `json:"checks"`
+}
+
+// Healthz — процесс жив и обслуживает запросы. Зависимости не проверяются,
+// чтобы оркестратор не перезапускал сервис из-за недоступной БД.
+func Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Checks:   map[string]Check{"process": {Status: checkOK}},
+		})
+	}
+}
+
+// Readyz — сервис готов принимать трафик: не идет остановка, БД отвечает
+// за timeout и схема на версии expectedVersion.
+func Readyz(log *slog.Logger, readiness *health.Readiness, deps Dependencies, expectedVersion int64, timeout time.Duration) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		checks := map[string]Check{
+			"shutdown":   checkShutdown(readiness),
+			"database":   toCheck(deps.Ping(ctx)),
+			"migrations": checkMigrations(ctx, deps, expectedVersion),
+		}
+
+		for name, check := range checks {
+			if check.Status != checkOK {
+				log.Warn("readiness check failed", slog.String("check", name), slog.String("error", check.Error))
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+				render.JSON(w, r, Response{Response: resp.Error("not ready"), Checks: checks})
+				return
+			}
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Checks: checks})
+	}
+}
+
+func checkShutdown(readiness *health.Readiness) Check {
+	if !readiness.Ready() {
+		return Check{Status: checkFail, Error: "shutting down"}
+	}
+	return Check{Status: checkOK}
+}
+
+func checkMigrations(ctx context.Context, deps Dependencies, expected int64) Check {
+	version, dirty, err := deps.SchemaVersion(ctx)
+	if err != nil {
+		return toCheck(err)
+	}
+	if dirty {
+		return Check{Status: checkFail, Error: fmt.Sprintf("migration %d is dirty", version)}
+	}
+	if version != expected {
+		return Check{Status: checkFail, Error: fmt.Sprintf("schema version %d, expected %d", version, expected)}
+	}
+	return Check{Status: checkOK}
+}
+
+func toCheck(err error) Check {
+	if err != nil {
+		return Check{Status: checkFail, Error: err.Error()}
+	}
+	return Check{Status: checkOK}
+}
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/health"
+	"wallet/internal/lib/api/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDependencies struct {
+	mock.Mock
+}
+
+func (m *MockDependencies) Ping(ctx context.Context) error {
+	return m.Called().Error(0)
+}
+
+func (m *MockDependencies) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name           string
+		ready          bool
+		pingErr        error
+		version        int64
+		dirty          bool
+		expectedStatus int
+		failedCheck    string
+	}{
+		{name: "ready", ready: true, version: 8, expectedStatus: http.StatusOK},
+		{name: "shutting down", ready: false, version: 8, expectedStatus: http.StatusServiceUnavailable, failedCheck: "shutdown"},
+		{name: "database down", ready: true, pingErr: errors.New("connection refused"), version: 8, expectedStatus: http.StatusServiceUnavailable, failedCheck: "database"},
+		{name: "migrations behind", ready: true, version: 7, expectedStatus: http.StatusServiceUnavailable, failedCheck: "migrations"},
+		{name: "dirty migration", ready: true, version: 8, dirty: true, expectedStatus: http.StatusServiceUnavailable, failedCheck: "migrations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := &health.Readiness{}
+			readiness.SetReady(tt.ready)
+
+			deps := new(MockDependencies)
+			deps.On("Ping").Return(tt.pingErr)
+			deps.On("SchemaVersion").Return(tt.version, tt.dirty, nil)
+
+			rec := httptest.NewRecorder()
+			Readyz(nil, readiness, deps, 8, time.Second).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Len(t, res.Checks, 3)
+
+			if tt.failedCheck == "" {
+				assert.Equal(t, response.StatusOK, res.Status)
+				return
+			}
+			assert.Equal(t, response.StatusError, res.Status)
+			for name, check := range res.Checks {
+				if name == tt.failedCheck {
+					assert.Equal(t, "FAIL", check.Status)
+				} else {
+					assert.Equal(t, "OK", check.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Healthz().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+var operationTypes = map[string]bool{
+	postgresql.OperationOpening:  true,
+	postgresql.OperationDeposit:  true,
+	postgresql.OperationWithdraw: true,
+
+	postgresql.OperationTransferOut: true,
+	postgresql.OperationTransferIn:  true,
+	postgresql.OperationCapture:     true,
+	postgresql.OperationAdjustment:  true,
+	postgresql.OperationFee:         true,
+}
+
+type OperationLister interface {
+	ListOperations(ctx context.Context, walletID uuid.UUID, filter postgresql.OperationFilter) ([]postgresql.Operation, error)
+}
+
+type Operation struct {
+	OperationID  uuid.UUID `json:"operation_id"`
+	Type         string    `json:"operation_type"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	Currency     string    `json:"currency"`
+	Rate         string    `json:"rate,omitempty"`
+	RequestID    string    `json:"request_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	WalletID   uuid.UUID   `json:"wallet_id"`
+	Operations []Operation `json:"operations"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// FetchOperations отдает журнал операций кошелька от новых к старым.
+// Параметры: limit, cursor (next_cursor предыдущей страницы), type (через
+// запятую), from и to (RFC3339, to не включается).
+func FetchOperations(log *slog.Logger, lister OperationLister) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.history.FetchOperations"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletUUIDStr := chi.URLParam(r, "WALLET_UUID")
+
+		walletUUID, err := uuid.Parse(walletUUIDStr)
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		filter, err := parseFilter(r)
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		filter.OwnerID = auth.OwnerScope(r.Context())
+
+		limit := filter.Limit
+		// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+		filter.Limit++
+
+		operations, err := lister.ListOperations(r.Context(), walletUUID, filter)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			if errors.Is(err, storage.ErrWalletNotFound) {
+				sender.SendError(w, r, log, http.StatusNotFound, "wallet does not exist", err)
+				return
+			}
+			if errors.Is(err, storage.ErrForbidden) {
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", err)
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to fetch operations", err)
+			return
+		}
+
+		var nextCursor string
+		if len(operations) > limit {
+			operations = operations[:limit]
+			nextCursor = encodeCursor(operations[limit-1].Seq)
+		}
+
+		result := make([]Operation, 0, len(operations))
+		for _, op := range operations {
+			result = append(result, newOperation(op))
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			WalletID:   walletUUID,
+			Operations: result,
+			NextCursor: nextCursor,
+		})
+	}
+}
+
+func newOperation(op postgresql.Operation) Operation {
+	return Operation{
+		OperationID:  op.OperationID,
+		Type:         op.Type,
+		Amount:       op.Amount,
+		BalanceAfter: op.BalanceAfter,
+		Currency:     op.Currency,
+		Rate:         op.Rate,
+		RequestID:    op.RequestID,
+		CreatedAt:    op.CreatedAt,
+	}
+}
+
+func parseFilter(r *http.Request) (postgresql.OperationFilter, error) {
+	query := r.URL.Query()
+
+	filter := postgresql.OperationFilter{Limit: defaultLimit}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		seq, err := decodeCursor(v)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.BeforeSeq = seq
+	}
+
+	if v := query.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToUpper(strings.TrimSpace(t))
+			if !operationTypes[t] {
+				return filter, fmt.Errorf("unsupported operation type %q", t)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	var err error
+	if filter.From, err = parseTime(query.Get("from")); err != nil {
+		return filter, errors.New("from must be RFC3339")
+	}
+	if filter.To, err = parseTime(query.Get("to")); err != nil {
+		return filter, errors.New("to must be RFC3339")
+	}
+
+	return filter, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func encodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq < 1 {
+		return 0, errors.New("invalid cursor")
+	}
+	return seq, nil
+}
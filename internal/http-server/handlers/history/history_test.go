@@ -0,0 +1,124 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOperationLister struct {
+	mock.Mock
+}
+
+func (m *MockOperationLister) ListOperations(ctx context.Context, walletID uuid.UUID, filter postgresql.OperationFilter) ([]postgresql.Operation, error) {
+	args := m.Called(walletID, filter)
+	return args.Get(0).([]postgresql.Operation), args.Error(1)
+}
+
+func TestFetchOperations(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ops := []postgresql.Operation{
+		{Seq: 30, OperationID: uuid.New(), WalletID: walletID, Type: postgresql.OperationWithdraw, Amount: -50, BalanceAfter: 150},
+		{Seq: 20, OperationID: uuid.New(), WalletID: walletID, Type: postgresql.OperationDeposit, Amount: 100, BalanceAfter: 200},
+		{Seq: 10, OperationID: uuid.New(), WalletID: walletID, Type: postgresql.OperationOpening, Amount: 100, BalanceAfter: 100},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockFilter     *postgresql.OperationFilter
+		mockOps        []postgresql.Operation
+		mockErr        error
+		expectedStatus int
+		expectedResp   response.Response
+		expectedCount  int
+		expectedCursor string
+	}{
+		{
+			name:           "first page with next cursor",
+			query:          "?limit=2",
+			mockFilter:     &postgresql.OperationFilter{Limit: 3},
+			mockOps:        ops,
+			expectedStatus: http.StatusOK,
+			expectedResp:   response.Response{Status: response.StatusOK},
+			expectedCount:  2,
+			expectedCursor: encodeCursor(20),
+		},
+		{
+			name:           "filters and cursor",
+			query:          "?cursor=" + encodeCursor(20) + "&type=deposit,OPENING&from=2025-01-01T00:00:00Z",
+			mockFilter:     &postgresql.OperationFilter{Limit: defaultLimit + 1, BeforeSeq: 20, Types: []string{"DEPOSIT", "OPENING"}, From: from},
+			mockOps:        ops[2:],
+			expectedStatus: http.StatusOK,
+			expectedResp:   response.Response{Status: response.StatusOK},
+			expectedCount:  1,
+		},
+		{
+			name:           "unknown type",
+			query:          "?type=REFUND",
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: `unsupported operation type "REFUND"`},
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=zzz",
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "invalid cursor"},
+		},
+		{
+			name:           "wallet not found",
+			mockFilter:     &postgresql.OperationFilter{Limit: defaultLimit + 1},
+			mockOps:        []postgresql.Operation(nil),
+			mockErr:        storage.ErrWalletNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet does not exist"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLister := new(MockOperationLister)
+			if tt.mockFilter != nil {
+				mockLister.On("ListOperations", walletID, *tt.mockFilter).Return(tt.mockOps, tt.mockErr)
+			}
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/wallets/{WALLET_UUID}/operations", FetchOperations(nil, mockLister))
+
+			req := httptest.NewRequest("GET", "/api/v1/wallets/"+walletID.String()+"/operations"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			fmt.Printf("Sending request: %s\n", req.URL)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tt.expectedResp, res.Response)
+			assert.Len(t, res.Operations, tt.expectedCount)
+			assert.Equal(t, tt.expectedCursor, res.NextCursor)
+
+			mockLister.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package history
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+// maxStatementOperations — больше операций в одну выписку не попадает,
+// период нужно сузить.
+const maxStatementOperations = 10000
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Строки CSV с балансом на начало и конец периода.
+const (
+	csvOpeningBalance = "OPENING_BALANCE"
+	csvClosingBalance = "CLOSING_BALANCE"
+)
+
+type StatementGetter interface {
+	GetStatement(ctx context.Context, walletID uuid.UUID, from, to time.Time, ownerID string, maxOperations int) (postgresql.Statement, error)
+}
+
+type StatementResponse struct {
+	resp.Response
+	WalletID       uuid.UUID   `json:"wallet_id"`
+	Currency       string      `json:"currency"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"`
+	OpeningBalance int64       `json:"opening_balance"`
+	ClosingBalance int64       `json:"closing_balance"`
+	Operations     []Operation `json:"operations"`
+}
+
+// FetchStatement отдает выписку за период [from, to): баланс на начало,
+// операции в порядке выполнения и баланс на конец. Параметры: from и to
+// (RFC3339, обязательные), format — json (по умолчанию) или csv.
+func FetchStatement(log *slog.Logger, getter StatementGetter) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.history.FetchStatement"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		from, to, format, err := parseStatementQuery(r)
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		statement, err := getter.GetStatement(r.Context(), walletID, from, to, auth.OwnerScope(r.Context()), maxStatementOperations)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			switch {
+			case errors.Is(err, storage.ErrWalletNotFound):
+				sender.SendError(w, r, log, http.StatusNotFound, "wallet does not exist", err)
+			case errors.Is(err, storage.ErrForbidden):
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", err)
+			case errors.Is(err, storage.ErrStatementTooLarge):
+				sender.SendError(w, r, log, http.StatusUnprocessableEntity,
+					fmt.Sprintf("more than %d operations in period, narrow the period", maxStatementOperations), err)
+			default:
+				sender.SendError(w, r, log, http.StatusInternalServerError, "failed to build statement", err)
+			}
+			return
+		}
+
+		if format == FormatCSV {
+			if err := writeStatementCSV(w, statement); err != nil {
+				log.Error("failed to write statement", sl.Err(err))
+			}
+			return
+		}
+
+		operations := make([]Operation, 0, len(statement.Operations))
+		for _, op := range statement.Operations {
+			operations = append(operations, newOperation(op))
+		}
+
+		render.JSON(w, r, StatementResponse{
+			Response:       resp.OK(),
+			WalletID:       statement.WalletID,
+			Currency:       statement.Currency,
+			From:           statement.From,
+			To:             statement.To,
+			OpeningBalance: statement.OpeningBalance,
+			ClosingBalance: statement.ClosingBalance,
+			Operations:     operations,
+		})
+	}
+}
+
+func parseStatementQuery(r *http.Request) (from, to time.Time, format string, err error) {
+	query := r.URL.Query()
+
+	if query.Get("from") == "" || query.Get("to") == "" {
+		return from, to, "", errors.New("from and to are required")
+	}
+	if from, err = parseTime(query.Get("from")); err != nil {
+		return from, to, "", errors.New("from must be RFC3339")
+	}
+	if to, err = parseTime(query.Get("to")); err != nil {
+		return from, to, "", errors.New("to must be RFC3339")
+	}
+	if !from.Before(to) {
+		return from, to, "", errors.New("from must be before to")
+	}
+
+	switch format = query.Get("format"); format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatCSV:
+	default:
+		return from, to, "", fmt.Errorf("unsupported format %q", format)
+	}
+
+	return from, to, format, nil
+}
+
+// writeStatementCSV пишет выписку одной таблицей: первая и последняя строки —
+// балансы на начало и конец периода, между ними операции.
+func writeStatementCSV(w http.ResponseWriter, statement postgresql.Statement) error {
+	filename := fmt.Sprintf("statement-%s-%s-%s.csv",
+		statement.WalletID, statement.From.UTC().Format("20060102T150405Z"), statement.To.UTC().Format("20060102T150405Z"))
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+
+	records := [][]string{
+		{"created_at", "operation_id", "operation_type", "amount", "balance_after", "currency", "rate", "request_id"},
+		{formatTime(statement.From), "", csvOpeningBalance, "", strconv.FormatInt(statement.OpeningBalance, 10), statement.Currency, "", ""},
+	}
+	for _, op := range statement.Operations {
+		records = append(records, []string{
+			formatTime(op.CreatedAt),
+			op.OperationID.String(),
+			op.Type,
+			strconv.FormatInt(op.Amount, 10),
+			strconv.FormatInt(op.BalanceAfter, 10),
+			op.Currency,
+			op.Rate,
+			op.RequestID,
+		})
+	}
+	records = append(records,
+		[]string{formatTime(statement.To), "", csvClosingBalance, "", strconv.FormatInt(statement.ClosingBalance, 10), statement.Currency, "", ""},
+	)
+
+	return cw.WriteAll(records)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
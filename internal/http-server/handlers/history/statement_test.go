@@ -0,0 +1,161 @@
+package history
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStatementGetter struct {
+	mock.Mock
+}
+
+func (m *MockStatementGetter) GetStatement(ctx context.Context, walletID uuid.UUID, from, to time.Time, ownerID string, maxOperations int) (postgresql.Statement, error) {
+	args := m.Called(walletID, from, to, ownerID, maxOperations)
+	return args.Get(0).(postgresql.Statement), args.Error(1)
+}
+
+var (
+	statementWallet = uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	statementFrom   = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	statementTo     = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func newStatement() postgresql.Statement {
+	return postgresql.Statement{
+		WalletID:       statementWallet,
+		Currency:       "USD",
+		From:           statementFrom,
+		To:             statementTo,
+		OpeningBalance: 1000,
+		ClosingBalance: 1250,
+		Operations: []postgresql.Operation{
+			{OperationID: uuid.New(), Type: postgresql.OperationDeposit, Amount: 500, BalanceAfter: 1500, Currency: "USD", CreatedAt: statementFrom.Add(time.Hour)},
+			{OperationID: uuid.New(), Type: postgresql.OperationWithdraw, Amount: -250, BalanceAfter: 1250, Currency: "USD", RequestID: "req-1", CreatedAt: statementFrom.Add(2 * time.Hour)},
+		},
+	}
+}
+
+func serveStatement(getter StatementGetter, query string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/api/v1/wallets/{WALLET_UUID}/statement", FetchStatement(nil, getter))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+statementWallet.String()+"/statement"+query, nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestFetchStatementJSON(t *testing.T) {
+	getter := new(MockStatementGetter)
+	getter.On("GetStatement", statementWallet, statementFrom, statementTo, "", maxStatementOperations).Return(newStatement(), nil)
+
+	rec := serveStatement(getter, "?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res StatementResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, int64(1000), res.OpeningBalance)
+	assert.Equal(t, int64(1250), res.ClosingBalance)
+	require.Len(t, res.Operations, 2)
+	assert.Equal(t, postgresql.OperationDeposit, res.Operations[0].Type)
+	getter.AssertExpectations(t)
+}
+
+func TestFetchStatementCSV(t *testing.T) {
+	getter := new(MockStatementGetter)
+	getter.On("GetStatement", statementWallet, statementFrom, statementTo, "", maxStatementOperations).Return(newStatement(), nil)
+
+	rec := serveStatement(getter, "?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&format=csv")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "statement-"+statementWallet.String())
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	assert.Equal(t, []string{"created_at", "operation_id", "operation_type", "amount", "balance_after", "currency", "rate", "request_id"}, records[0])
+	assert.Equal(t, []string{"2025-01-01T00:00:00Z", "", "OPENING_BALANCE", "", "1000", "USD", "", ""}, records[1])
+	assert.Equal(t, "-250", records[3][3])
+	assert.Equal(t, "req-1", records[3][7])
+	assert.Equal(t, []string{"2025-02-01T00:00:00Z", "", "CLOSING_BALANCE", "", "1250", "USD", "", ""}, records[4])
+}
+
+func TestFetchStatementErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockErr        error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "missing period",
+			query:          "?from=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "from and to are required",
+		},
+		{
+			name:           "reversed period",
+			query:          "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "from must be before to",
+		},
+		{
+			name:           "unsupported format",
+			query:          "?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&format=xml",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `unsupported format "xml"`,
+		},
+		{
+			name:           "wallet not found",
+			query:          "?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z",
+			mockErr:        storage.ErrWalletNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "wallet does not exist",
+		},
+		{
+			name:           "too many operations",
+			query:          "?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z",
+			mockErr:        storage.ErrStatementTooLarge,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "more than 10000 operations in period, narrow the period",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := new(MockStatementGetter)
+			if tt.mockErr != nil {
+				getter.On("GetStatement", statementWallet, statementFrom, statementTo, "", maxStatementOperations).
+					Return(postgresql.Statement{}, tt.mockErr)
+			}
+
+			rec := serveStatement(getter, tt.query)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res response.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			getter.AssertExpectations(t)
+		})
+	}
+}
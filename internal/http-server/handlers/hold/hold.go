@@ -0,0 +1,288 @@
+package hold
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/handlers/transaction"
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+type Holder interface {
+	CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, currency string, ttl time.Duration, meta postgresql.OperationMeta) (postgresql.HoldResult, error)
+	CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, meta postgresql.OperationMeta) (postgresql.HoldResult, error)
+	VoidHold(ctx context.Context, walletID, holdID uuid.UUID, meta postgresql.OperationMeta) (postgresql.HoldResult, error)
+}
+
+// CreateRequest — TTLSeconds необязателен, по умолчанию используется
+// HOLDS_DEFAULT_TTL.
+type CreateRequest struct {
+	Amount     int64  `json:"amount" validate:"required,min=1"`
+	Currency   string `json:"currency" validate:"required,iso4217"`
+	TTLSeconds int64  `json:"ttl_seconds" validate:"min=0"`
+}
+
+// CaptureRequest — без amount захватывается вся сумма холда.
+type CaptureRequest struct {
+	Amount int64 `json:"amount" validate:"min=0"`
+}
+
+type Hold struct {
+	HoldID         uuid.UUID `json:"hold_id"`
+	Amount         int64     `json:"amount"`
+	CapturedAmount int64     `json:"captured_amount"`
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type Wallet struct {
+	WalletID  uuid.UUID `json:"wallet_id"`
+	Balance   int64     `json:"balance"`
+	Available int64     `json:"available"`
+	Currency  string    `json:"currency"`
+}
+
+type Response struct {
+	resp.Response
+	Hold   Hold   `json:"hold"`
+	Wallet Wallet `json:"wallet"`
+}
+
+func CreateHold(log *slog.Logger, holder Holder, defaultTTL, maxTTL time.Duration) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.hold.CreateHold"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		var req CreateRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if !validate(w, r, log, req) {
+			return
+		}
+
+		// Секунды сравниваются с пределом до умножения: большое ttl_seconds
+		// переполнило бы time.Duration и стало бы отрицательным.
+		if req.TTLSeconds > int64(maxTTL/time.Second) {
+			sender.SendError(w, r, log, http.StatusBadRequest, fmt.Sprintf("ttl must not exceed %s", maxTTL), errors.New("ttl too long"))
+			return
+		}
+
+		ttl := defaultTTL
+		if req.TTLSeconds > 0 {
+			ttl = time.Duration(req.TTLSeconds) * time.Second
+		}
+		if ttl <= 0 || ttl > maxTTL {
+			sender.SendError(w, r, log, http.StatusBadRequest, fmt.Sprintf("ttl must be between 1s and %s", maxTTL), fmt.Errorf("invalid ttl %s", ttl))
+			return
+		}
+
+		if transaction.SendReservedKeyError(w, r, log, r.Header.Get(transaction.IdempotencyKeyHeader)) {
+			return
+		}
+		meta := newMeta(r, fmt.Sprintf("HOLD|%s|%d|%s|%d", walletID, req.Amount, req.Currency, req.TTLSeconds))
+
+		res, err := holder.CreateHold(r.Context(), walletID, req.Amount, req.Currency, ttl, meta)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("hold created", slog.String("hold_id", res.Hold.HoldID.String()))
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, newResponse(res))
+	}
+}
+
+func CaptureHold(log *slog.Logger, holder Holder) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.hold.CaptureHold"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, holdID, ok := parseIDs(w, r, log)
+		if !ok {
+			return
+		}
+
+		var req CaptureRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if !validate(w, r, log, req) {
+			return
+		}
+
+		if transaction.SendReservedKeyError(w, r, log, r.Header.Get(transaction.IdempotencyKeyHeader)) {
+			return
+		}
+		meta := newMeta(r, fmt.Sprintf("CAPTURE|%s|%d", holdID, req.Amount))
+
+		res, err := holder.CaptureHold(r.Context(), walletID, holdID, req.Amount, meta)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("hold captured",
+			slog.String("hold_id", holdID.String()),
+			slog.Int64("amount", res.Hold.CapturedAmount),
+		)
+
+		render.JSON(w, r, newResponse(res))
+	}
+}
+
+func VoidHold(log *slog.Logger, holder Holder) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.hold.VoidHold"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, holdID, ok := parseIDs(w, r, log)
+		if !ok {
+			return
+		}
+
+		if transaction.SendReservedKeyError(w, r, log, r.Header.Get(transaction.IdempotencyKeyHeader)) {
+			return
+		}
+		meta := newMeta(r, fmt.Sprintf("VOID|%s", holdID))
+
+		res, err := holder.VoidHold(r.Context(), walletID, holdID, meta)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("hold voided", slog.String("hold_id", holdID.String()))
+
+		render.JSON(w, r, newResponse(res))
+	}
+}
+
+func parseIDs(w http.ResponseWriter, r *http.Request, log *slog.Logger) (uuid.UUID, uuid.UUID, bool) {
+	walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+	if err != nil {
+		sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	holdID, err := uuid.Parse(chi.URLParam(r, "HOLD_UUID"))
+	if err != nil {
+		sender.SendError(w, r, log, http.StatusBadRequest, "invalid hold UUID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return walletID, holdID, true
+}
+
+func validate(w http.ResponseWriter, r *http.Request, log *slog.Logger, req any) bool {
+	if err := validator.New().Struct(req); err != nil {
+		log.Error("invalid request", sl.Err(err))
+		validatorErr := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, resp.ValidationError(validatorErr))
+		return false
+	}
+	return true
+}
+
+func newMeta(r *http.Request, request string) postgresql.OperationMeta {
+	sum := sha256.Sum256([]byte(request))
+
+	return postgresql.OperationMeta{
+		RequestID:      middleware.GetReqID(r.Context()),
+		IdempotencyKey: r.Header.Get(transaction.IdempotencyKeyHeader),
+		Fingerprint:    hex.EncodeToString(sum[:]),
+		OwnerID:        auth.OwnerScope(r.Context()),
+	}
+}
+
+// sendError отвечает на ошибки, специфичные для холдов; остальные — как
+// любая операция с балансом.
+func sendError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, storage.ErrHoldNotFound):
+		sender.SendError(w, r, log, http.StatusNotFound, "hold not found", err)
+	case errors.Is(err, storage.ErrHoldNotActive):
+		sender.SendError(w, r, log, http.StatusConflict, "hold is not active", err)
+	case errors.Is(err, storage.ErrHoldExpired):
+		sender.SendError(w, r, log, http.StatusConflict, "hold has expired", err)
+	case errors.Is(err, storage.ErrHoldAmountExceeded):
+		sender.SendError(w, r, log, http.StatusUnprocessableEntity, "capture amount exceeds hold amount", err)
+	default:
+		transaction.SendOperationError(w, r, log, err)
+	}
+}
+
+func newResponse(res postgresql.HoldResult) Response {
+	return Response{
+		Response: resp.OK(),
+		Hold: Hold{
+			HoldID:         res.Hold.HoldID,
+			Amount:         res.Hold.Amount,
+			CapturedAmount: res.Hold.CapturedAmount,
+			Currency:       res.Hold.Currency,
+			Status:         res.Hold.Status,
+			ExpiresAt:      res.Hold.ExpiresAt,
+			CreatedAt:      res.Hold.CreatedAt,
+		},
+		Wallet: Wallet{
+			WalletID:  res.Wallet.WalletID,
+			Balance:   res.Wallet.Balance,
+			Available: res.Wallet.Available(),
+			Currency:  res.Wallet.Currency,
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package hold
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHolder struct {
+	mock.Mock
+}
+
+func (m *MockHolder) CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, currency string, ttl time.Duration, meta postgresql.OperationMeta) (postgresql.HoldResult, error) {
+	args := m.Called(walletID, amount, currency, ttl, meta)
+	return args.Get(0).(postgresql.HoldResult), args.Error(1)
+}
+
+func (m *MockHolder) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, meta postgresql.OperationMeta) (postgresql.HoldResult, error) {
+	args := m.Called(walletID, holdID, amount, meta)
+	return args.Get(0).(postgresql.HoldResult), args.Error(1)
+}
+
+func (m *MockHolder) VoidHold(ctx context.Context, walletID, holdID uuid.UUID, meta postgresql.OperationMeta) (postgresql.HoldResult, error) {
+	args := m.Called(walletID, holdID, meta)
+	return args.Get(0).(postgresql.HoldResult), args.Error(1)
+}
+
+var (
+	walletID = uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	holdID   = uuid.MustParse("a45c73fd-3e36-466a-8e57-15e1cf0f35d2")
+)
+
+func newRouter(holder Holder) *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/api/v1/wallets/{WALLET_UUID}/holds", CreateHold(nil, holder, 15*time.Minute, time.Hour))
+	r.Post("/api/v1/wallets/{WALLET_UUID}/holds/{HOLD_UUID}/capture", CaptureHold(nil, holder))
+	r.Post("/api/v1/wallets/{WALLET_UUID}/holds/{HOLD_UUID}/void", VoidHold(nil, holder))
+	return r
+}
+
+func TestHolds(t *testing.T) {
+	holdResult := postgresql.HoldResult{
+		Hold:   postgresql.Hold{HoldID: holdID, WalletID: walletID, Amount: 30, Currency: "USD", Status: postgresql.HoldActive},
+		Wallet: postgresql.Wallet{WalletID: walletID, Balance: 100, Held: 30, Currency: "USD"},
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		body              string
+		setup             func(m *MockHolder)
+		expectedStatus    int
+		expectedResp      response.Response
+		expectedAvailable int64
+	}{
+		{
+			name: "create with default ttl",
+			path: "/holds",
+			body: `{"amount":30,"currency":"USD"}`,
+			setup: func(m *MockHolder) {
+				m.On("CreateHold", walletID, int64(30), "USD", 15*time.Minute, mock.Anything).Return(holdResult, nil)
+			},
+			expectedStatus:    http.StatusCreated,
+			expectedResp:      response.Response{Status: response.StatusOK},
+			expectedAvailable: 70,
+		},
+		{
+			name: "create with custom ttl",
+			path: "/holds",
+			body: `{"amount":30,"currency":"USD","ttl_seconds":60}`,
+			setup: func(m *MockHolder) {
+				m.On("CreateHold", walletID, int64(30), "USD", time.Minute, mock.Anything).Return(holdResult, nil)
+			},
+			expectedStatus:    http.StatusCreated,
+			expectedResp:      response.Response{Status: response.StatusOK},
+			expectedAvailable: 70,
+		},
+		{
+			name:           "create with ttl above max",
+			path:           "/holds",
+			body:           `{"amount":30,"currency":"USD","ttl_seconds":7200}`,
+			setup:          func(m *MockHolder) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "ttl must not exceed 1h0m0s"},
+		},
+		{
+			name:           "create with ttl overflowing duration",
+			path:           "/holds",
+			body:           `{"amount":30,"currency":"USD","ttl_seconds":9300000000}`,
+			setup:          func(m *MockHolder) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "ttl must not exceed 1h0m0s"},
+		},
+		{
+			name: "create with insufficient funds",
+			path: "/holds",
+			body: `{"amount":300,"currency":"USD"}`,
+			setup: func(m *MockHolder) {
+				m.On("CreateHold", walletID, int64(300), "USD", 15*time.Minute, mock.Anything).Return(postgresql.HoldResult{}, storage.ErrInsufficientFunds)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "insufficient funds"},
+		},
+		{
+			name: "create on frozen wallet",
+			path: "/holds",
+			body: `{"amount":30,"currency":"USD"}`,
+			setup: func(m *MockHolder) {
+				m.On("CreateHold", walletID, int64(30), "USD", 15*time.Minute, mock.Anything).Return(postgresql.HoldResult{}, storage.ErrWalletFrozen)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet is frozen"},
+		},
+		{
+			name: "full capture without body",
+			path: "/holds/" + holdID.String() + "/capture",
+			setup: func(m *MockHolder) {
+				m.On("CaptureHold", walletID, holdID, int64(0), mock.Anything).Return(postgresql.HoldResult{
+					Hold:   postgresql.Hold{HoldID: holdID, Amount: 30, CapturedAmount: 30, Status: postgresql.HoldCaptured},
+					Wallet: postgresql.Wallet{WalletID: walletID, Balance: 70},
+				}, nil)
+			},
+			expectedStatus:    http.StatusOK,
+			expectedResp:      response.Response{Status: response.StatusOK},
+			expectedAvailable: 70,
+		},
+		{
+			name: "partial capture above hold",
+			path: "/holds/" + holdID.String() + "/capture",
+			body: `{"amount":50}`,
+			setup: func(m *MockHolder) {
+				m.On("CaptureHold", walletID, holdID, int64(50), mock.Anything).Return(postgresql.HoldResult{}, storage.ErrHoldAmountExceeded)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "capture amount exceeds hold amount"},
+		},
+		{
+			name: "void inactive hold",
+			path: "/holds/" + holdID.String() + "/void",
+			setup: func(m *MockHolder) {
+				m.On("VoidHold", walletID, holdID, mock.Anything).Return(postgresql.HoldResult{}, storage.ErrHoldNotActive)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "hold is not active"},
+		},
+		{
+			name: "capture expired hold",
+			path: "/holds/" + holdID.String() + "/capture",
+			setup: func(m *MockHolder) {
+				m.On("CaptureHold", walletID, holdID, int64(0), mock.Anything).Return(postgresql.HoldResult{}, storage.ErrHoldExpired)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "hold has expired"},
+		},
+		{
+			name:           "invalid hold id",
+			path:           "/holds/not-a-uuid/void",
+			setup:          func(m *MockHolder) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "invalid hold UUID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHolder := new(MockHolder)
+			tt.setup(mockHolder)
+
+			req := httptest.NewRequest("POST", "/api/v1/wallets/"+walletID.String()+tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			fmt.Printf("Sending request: %s\n", req.URL)
+
+			newRouter(mockHolder).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tt.expectedResp, res.Response)
+			assert.Equal(t, tt.expectedAvailable, res.Wallet.Available)
+
+			mockHolder.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateHoldNonPositiveTTL(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/api/v1/wallets/{WALLET_UUID}/holds", CreateHold(nil, new(MockHolder), 0, time.Hour))
+
+	req := httptest.NewRequest("POST", "/api/v1/wallets/"+uuid.NewString()+"/holds", bytes.NewBufferString(`{"amount":30,"currency":"USD"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res response.Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, "ttl must be between 1s and 1h0m0s", res.Error)
+}
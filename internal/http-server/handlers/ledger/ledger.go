@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/storage/postgresql"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Ledger interface {
+	ListAccountBalances(ctx context.Context, prefix string) ([]postgresql.AccountBalance, error)
+	ListJournalEntries(ctx context.Context, account string, beforeSeq int64, limit int) ([]postgresql.JournalEntry, error)
+}
+
+type AccountBalance struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+type Posting struct {
+	Account  string `json:"account"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type Entry struct {
+	EntryID   uuid.UUID `json:"entry_id"`
+	Type      string    `json:"entry_type"`
+	RequestID string    `json:"request_id,omitempty"`
+	Postings  []Posting `json:"postings"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountsResponse struct {
+	resp.Response
+	Accounts []AccountBalance `json:"accounts"`
+}
+
+type EntriesResponse struct {
+	resp.Response
+	Entries    []Entry `json:"entries"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// FetchAccounts отдает остатки счетов. Параметр prefix, например "wallet:"
+// или "fees:", оставляет только счета с этим префиксом.
+func FetchAccounts(log *slog.Logger, ledger Ledger) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.ledger.FetchAccounts"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		balances, err := ledger.ListAccountBalances(r.Context(), r.URL.Query().Get("prefix"))
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to fetch accounts", err)
+			return
+		}
+
+		accounts := make([]AccountBalance, 0, len(balances))
+		for _, b := range balances {
+			accounts = append(accounts, AccountBalance{Account: b.Account, Currency: b.Currency, Balance: b.Balance})
+		}
+
+		render.JSON(w, r, AccountsResponse{Response: resp.OK(), Accounts: accounts})
+	}
+}
+
+// FetchEntries отдает проводки от новых к старым. Параметры: account,
+// limit, cursor (next_cursor предыдущей страницы).
+func FetchEntries(log *slog.Logger, ledger Ledger) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.ledger.FetchEntries"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		limit := defaultLimit
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxLimit {
+				err := fmt.Errorf("limit must be between 1 and %d", maxLimit)
+				sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+				return
+			}
+			limit = n
+		}
+
+		var beforeSeq int64
+		if v := query.Get("cursor"); v != "" {
+			seq, err := decodeCursor(v)
+			if err != nil {
+				sender.SendError(w, r, log, http.StatusBadRequest, "invalid cursor", err)
+				return
+			}
+			beforeSeq = seq
+		}
+
+		// На одну запись больше, чтобы понять, есть ли следующая страница.
+		entries, err := ledger.ListJournalEntries(r.Context(), query.Get("account"), beforeSeq, limit+1)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to fetch entries", err)
+			return
+		}
+
+		var nextCursor string
+		if len(entries) > limit {
+			entries = entries[:limit]
+			nextCursor = encodeCursor(entries[limit-1].Seq)
+		}
+
+		result := make([]Entry, 0, len(entries))
+		for _, e := range entries {
+			postings := make([]Posting, 0, len(e.Postings))
+			for _, p := range e.Postings {
+				postings = append(postings, Posting{Account: p.Account, Amount: p.Amount, Currency: p.Currency})
+			}
+			result = append(result, Entry{
+				EntryID:   e.EntryID,
+				Type:      e.Type,
+				RequestID: e.RequestID,
+				Postings:  postings,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, EntriesResponse{Response: resp.OK(), Entries: result, NextCursor: nextCursor})
+	}
+}
+
+func encodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq <= 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return seq, nil
+}
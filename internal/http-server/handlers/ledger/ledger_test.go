@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockLedger struct {
+	mock.Mock
+}
+
+func (m *MockLedger) ListAccountBalances(ctx context.Context, prefix string) ([]postgresql.AccountBalance, error) {
+	args := m.Called(prefix)
+	return args.Get(0).([]postgresql.AccountBalance), args.Error(1)
+}
+
+func (m *MockLedger) ListJournalEntries(ctx context.Context, account string, beforeSeq int64, limit int) ([]postgresql.JournalEntry, error) {
+	args := m.Called(account, beforeSeq, limit)
+	return args.Get(0).([]postgresql.JournalEntry), args.Error(1)
+}
+
+func newRouter(ledger Ledger) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/api/v1/admin/ledger/accounts", FetchAccounts(nil, ledger))
+	r.Get("/api/v1/admin/ledger/entries", FetchEntries(nil, ledger))
+	return r
+}
+
+func TestFetchAccounts(t *testing.T) {
+	ledger := new(MockLedger)
+	ledger.On("ListAccountBalances", "fees:").Return([]postgresql.AccountBalance{
+		{Account: postgresql.AccountFeesRevenue, Currency: "USD", Balance: 350},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/ledger/accounts?prefix=fees:", nil)
+	rec := httptest.NewRecorder()
+	newRouter(ledger).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res AccountsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, []AccountBalance{{Account: "fees:revenue", Currency: "USD", Balance: 350}}, res.Accounts)
+	ledger.AssertExpectations(t)
+}
+
+func TestFetchEntries(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	account := postgresql.WalletAccount(walletID)
+
+	entries := []postgresql.JournalEntry{
+		{Seq: 30, EntryID: uuid.New(), Type: postgresql.OperationWithdraw, Postings: []postgresql.Posting{
+			{Account: account, Amount: -50, Currency: "USD"},
+			{Account: postgresql.AccountExternalBank, Amount: 50, Currency: "USD"},
+		}},
+		{Seq: 20, EntryID: uuid.New(), Type: postgresql.OperationDeposit, Postings: []postgresql.Posting{
+			{Account: postgresql.AccountExternalBank, Amount: -100, Currency: "USD"},
+			{Account: account, Amount: 100, Currency: "USD"},
+		}},
+		{Seq: 10, EntryID: uuid.New(), Type: postgresql.OperationOpening},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(m *MockLedger)
+		expectedStatus int
+		expectedError  string
+		expectedCount  int
+		expectedCursor string
+	}{
+		{
+			name:  "first page",
+			query: "?account=" + account + "&limit=2",
+			mockSetup: func(m *MockLedger) {
+				m.On("ListJournalEntries", account, int64(0), 3).Return(entries, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+			expectedCursor: encodeCursor(20),
+		},
+		{
+			name:  "last page",
+			query: "?cursor=" + encodeCursor(20),
+			mockSetup: func(m *MockLedger) {
+				m.On("ListJournalEntries", "", int64(20), defaultLimit+1).Return(entries[2:], nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=not-a-cursor!",
+			mockSetup:      func(m *MockLedger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid cursor",
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=0",
+			mockSetup:      func(m *MockLedger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "limit must be between 1 and 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := new(MockLedger)
+			tt.mockSetup(ledger)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/ledger/entries"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			newRouter(ledger).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedError != "" {
+				var res response.Response
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				assert.Equal(t, tt.expectedError, res.Error)
+				return
+			}
+
+			var res EntriesResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Len(t, res.Entries, tt.expectedCount)
+			assert.Equal(t, tt.expectedCursor, res.NextCursor)
+			ledger.AssertExpectations(t)
+		})
+	}
+}
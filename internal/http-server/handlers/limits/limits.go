@@ -0,0 +1,285 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+// anonymousActor записывается в журнал кредитной линии, когда
+// аутентификация отключена.
+const anonymousActor = "anonymous"
+
+type LimitsStore interface {
+	GetWalletLimits(ctx context.Context, walletID uuid.UUID) (postgresql.Limits, error)
+	SetWalletLimits(ctx context.Context, walletID uuid.UUID, limits postgresql.Limits) (postgresql.Limits, error)
+}
+
+type CreditLimitSetter interface {
+	SetCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit int64, change postgresql.CreditLimitChange) (postgresql.Wallet, error)
+}
+
+type TierSetter interface {
+	SetWalletTier(ctx context.Context, walletID uuid.UUID, tier string) (postgresql.Wallet, error)
+}
+
+// Limits — значения в минимальных единицах валюты кошелька; null или
+// отсутствие поля — без ограничения.
+type Limits struct {
+	MaxWithdrawal     *int64 `json:"max_withdrawal" validate:"omitempty,min=0"`
+	DailyWithdrawal   *int64 `json:"daily_withdrawal" validate:"omitempty,min=0"`
+	MonthlyWithdrawal *int64 `json:"monthly_withdrawal" validate:"omitempty,min=0"`
+	MaxBalance        *int64 `json:"max_balance" validate:"omitempty,min=0"`
+}
+
+// CreditLimitRequest — 0 отключает кредитную линию.
+type CreditLimitRequest struct {
+	CreditLimit *int64 `json:"credit_limit" validate:"required,min=0"`
+}
+
+// TierRequest — тариф, по которому считаются комиссии кошелька. Тариф без
+// своих правил получает правила default.
+type TierRequest struct {
+	Tier string `json:"tier" validate:"required,max=64,printascii,excludesall= "`
+}
+
+type Response struct {
+	resp.Response
+	WalletID  uuid.UUID  `json:"wallet_id"`
+	Limits    Limits     `json:"limits"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func FetchLimits(log *slog.Logger, store LimitsStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.limits.FetchLimits"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		limits, err := store.GetWalletLimits(r.Context(), walletID)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		render.JSON(w, r, newResponse(walletID, limits))
+	}
+}
+
+// UpdateLimits заменяет все лимиты кошелька целиком.
+func UpdateLimits(log *slog.Logger, store LimitsStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.limits.UpdateLimits"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		var req Limits
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		var updatedBy string
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			updatedBy = principal.ClientID
+		}
+
+		limits, err := store.SetWalletLimits(r.Context(), walletID, postgresql.Limits{
+			MaxWithdrawal:     req.MaxWithdrawal,
+			DailyWithdrawal:   req.DailyWithdrawal,
+			MonthlyWithdrawal: req.MonthlyWithdrawal,
+			MaxBalance:        req.MaxBalance,
+			UpdatedBy:         updatedBy,
+		})
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("wallet limits updated", slog.String("wallet_id", walletID.String()), slog.String("updated_by", updatedBy))
+
+		render.JSON(w, r, newResponse(walletID, limits))
+	}
+}
+
+func UpdateCreditLimit(log *slog.Logger, setter CreditLimitSetter) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.limits.UpdateCreditLimit"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		var req CreditLimitRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		actor := anonymousActor
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			actor = principal.ClientID
+		}
+
+		wallet, err := setter.SetCreditLimit(r.Context(), walletID, *req.CreditLimit, postgresql.CreditLimitChange{
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("credit limit updated",
+			slog.String("wallet_id", walletID.String()),
+			slog.Int64("credit_limit", wallet.CreditLimit),
+			slog.String("actor", actor),
+		)
+
+		render.JSON(w, r, getter.NewResponse(wallet))
+	}
+}
+
+func UpdateTier(log *slog.Logger, setter TierSetter) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.limits.UpdateTier"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		var req TierRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		wallet, err := setter.SetWalletTier(r.Context(), walletID, req.Tier)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("tier updated",
+			slog.String("wallet_id", walletID.String()),
+			slog.String("tier", wallet.Tier),
+		)
+
+		render.JSON(w, r, getter.NewResponse(wallet))
+	}
+}
+
+func newResponse(walletID uuid.UUID, limits postgresql.Limits) Response {
+	res := Response{
+		Response: resp.OK(),
+		WalletID: walletID,
+		Limits: Limits{
+			MaxWithdrawal:     limits.MaxWithdrawal,
+			DailyWithdrawal:   limits.DailyWithdrawal,
+			MonthlyWithdrawal: limits.MonthlyWithdrawal,
+			MaxBalance:        limits.MaxBalance,
+		},
+		UpdatedBy: limits.UpdatedBy,
+	}
+	if !limits.UpdatedAt.IsZero() {
+		res.UpdatedAt = &limits.UpdatedAt
+	}
+	return res
+}
+
+func sendError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if sender.SendContextError(w, r, log, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, storage.ErrWalletNotFound):
+		sender.SendError(w, r, log, http.StatusNotFound, "walletId not found", err)
+	default:
+		sender.SendError(w, r, log, http.StatusInternalServerError, "failed to process limits", err)
+	}
+}
@@ -0,0 +1,298 @@
+package limits
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLimitsStore struct {
+	mock.Mock
+}
+
+func (m *MockLimitsStore) GetWalletLimits(ctx context.Context, walletID uuid.UUID) (postgresql.Limits, error) {
+	args := m.Called(walletID)
+	return args.Get(0).(postgresql.Limits), args.Error(1)
+}
+
+func (m *MockLimitsStore) SetWalletLimits(ctx context.Context, walletID uuid.UUID, limits postgresql.Limits) (postgresql.Limits, error) {
+	args := m.Called(walletID, limits)
+	return args.Get(0).(postgresql.Limits), args.Error(1)
+}
+
+var walletID = uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+func newRouter(store LimitsStore) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/api/v1/admin/wallets/{WALLET_UUID}/limits", FetchLimits(nil, store))
+	r.Put("/api/v1/admin/wallets/{WALLET_UUID}/limits", UpdateLimits(nil, store))
+	return r
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestFetchLimits(t *testing.T) {
+	store := new(MockLimitsStore)
+	store.On("GetWalletLimits", walletID).Return(postgresql.Limits{DailyWithdrawal: int64Ptr(50000)}, nil)
+	store.On("GetWalletLimits", mock.Anything).Return(postgresql.Limits{}, storage.ErrWalletNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+walletID.String()+"/limits", nil)
+	rec := httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"max_withdrawal": null, "daily_withdrawal": 50000, "monthly_withdrawal": null, "max_balance": null}`,
+		string(mustField(t, rec.Body.Bytes(), "limits")))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+uuid.NewString()+"/limits", nil)
+	rec = httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUpdateLimits(t *testing.T) {
+	admin, err := auth.NewPrincipal("risk-team", "admin")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *MockLimitsStore)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "set limits",
+			body: `{"max_withdrawal": 10000, "monthly_withdrawal": 200000}`,
+			mockSetup: func(m *MockLimitsStore) {
+				m.On("SetWalletLimits", walletID, postgresql.Limits{
+					MaxWithdrawal:     int64Ptr(10000),
+					MonthlyWithdrawal: int64Ptr(200000),
+					UpdatedBy:         "risk-team",
+				}).Return(postgresql.Limits{MaxWithdrawal: int64Ptr(10000), MonthlyWithdrawal: int64Ptr(200000), UpdatedBy: "risk-team"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "negative limit",
+			body:           `{"max_balance": -1}`,
+			mockSetup:      func(m *MockLimitsStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field MaxBalance is not valid",
+		},
+		{
+			name: "wallet not found",
+			body: `{}`,
+			mockSetup: func(m *MockLimitsStore) {
+				m.On("SetWalletLimits", walletID, mock.Anything).Return(postgresql.Limits{}, storage.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "walletId not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockLimitsStore)
+			tt.mockSetup(store)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID.String()+"/limits", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithPrincipal(req.Context(), admin))
+			rec := httptest.NewRecorder()
+
+			newRouter(store).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res response.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func mustField(t *testing.T, body []byte, field string) json.RawMessage {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(body, &fields))
+	return fields[field]
+}
+
+type MockCreditLimitSetter struct {
+	mock.Mock
+}
+
+func (m *MockCreditLimitSetter) SetCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit int64, change postgresql.CreditLimitChange) (postgresql.Wallet, error) {
+	args := m.Called(walletID, creditLimit, change.Actor)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+func TestUpdateCreditLimit(t *testing.T) {
+	admin, err := auth.NewPrincipal("risk-team", "admin")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *MockCreditLimitSetter)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "open credit line",
+			body: `{"credit_limit": 50000}`,
+			mockSetup: func(m *MockCreditLimitSetter) {
+				m.On("SetCreditLimit", walletID, int64(50000), "risk-team").
+					Return(postgresql.Wallet{WalletID: walletID, Balance: 100, CreditLimit: 50000, Currency: "USD"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "close credit line",
+			body: `{"credit_limit": 0}`,
+			mockSetup: func(m *MockCreditLimitSetter) {
+				m.On("SetCreditLimit", walletID, int64(0), "risk-team").
+					Return(postgresql.Wallet{WalletID: walletID, Currency: "USD"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing credit limit",
+			body:           `{}`,
+			mockSetup:      func(m *MockCreditLimitSetter) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field CreditLimit is a required field",
+		},
+		{
+			name:           "negative credit limit",
+			body:           `{"credit_limit": -5}`,
+			mockSetup:      func(m *MockCreditLimitSetter) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field CreditLimit is not valid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setter := new(MockCreditLimitSetter)
+			tt.mockSetup(setter)
+
+			r := chi.NewRouter()
+			r.Put("/api/v1/admin/wallets/{WALLET_UUID}/credit-limit", UpdateCreditLimit(nil, setter))
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID.String()+"/credit-limit", bytes.NewBufferString(tt.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), admin))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res response.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			setter.AssertExpectations(t)
+		})
+	}
+}
+
+type MockTierSetter struct {
+	mock.Mock
+}
+
+func (m *MockTierSetter) SetWalletTier(ctx context.Context, walletID uuid.UUID, tier string) (postgresql.Wallet, error) {
+	args := m.Called(walletID, tier)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+func TestUpdateTier(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *MockTierSetter)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "set tier",
+			body: `{"tier":"premium"}`,
+			mockSetup: func(m *MockTierSetter) {
+				m.On("SetWalletTier", walletID, "premium").
+					Return(postgresql.Wallet{WalletID: walletID, Currency: "USD", Tier: "premium"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "wallet not found",
+			body: `{"tier":"premium"}`,
+			mockSetup: func(m *MockTierSetter) {
+				m.On("SetWalletTier", walletID, "premium").Return(postgresql.Wallet{}, storage.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "walletId not found",
+		},
+		{
+			name:           "missing tier",
+			body:           `{}`,
+			mockSetup:      func(m *MockTierSetter) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field Tier is a required field",
+		},
+		{
+			name:           "tier with spaces",
+			body:           `{"tier":"gold plus"}`,
+			mockSetup:      func(m *MockTierSetter) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field Tier is not valid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setter := new(MockTierSetter)
+			tt.mockSetup(setter)
+
+			r := chi.NewRouter()
+			r.Put("/api/v1/admin/wallets/{WALLET_UUID}/tier", UpdateTier(nil, setter))
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID.String()+"/tier", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res getter.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			if tt.expectedError == "" {
+				assert.Equal(t, "premium", res.Tier)
+			}
+			setter.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package lister
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/currency"
+	"wallet/storage/postgresql"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+var sortKeys = map[string]bool{
+	postgresql.WalletSortID:        true,
+	postgresql.WalletSortBalance:   true,
+	postgresql.WalletSortCreatedAt: true,
+}
+
+var statuses = map[string]bool{
+	postgresql.WalletActive: true,
+	postgresql.WalletFrozen: true,
+	postgresql.WalletClosed: true,
+}
+
+type WalletLister interface {
+	ListWallets(ctx context.Context, filter postgresql.WalletFilter) ([]postgresql.Wallet, error)
+}
+
+type Wallet struct {
+	WalletID    uuid.UUID `json:"wallet_id"`
+	Balance     int64     `json:"balance"`
+	Available   int64     `json:"available"`
+	CreditLimit int64     `json:"credit_limit"`
+	Currency    string    `json:"currency"`
+	Amount      string    `json:"amount"`
+	OwnerID     string    `json:"owner_id,omitempty"`
+	Status      string    `json:"wallet_status"`
+	Tier        string    `json:"tier,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Wallets    []Wallet `json:"wallets"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// cursor — содержимое next_cursor. Сортировка хранится вместе с позицией:
+// курсор от одной сортировки не подходит для другой.
+type cursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	WalletID  uuid.UUID `json:"id"`
+	Balance   int64     `json:"b,omitempty"`
+	CreatedAt time.Time `json:"c"`
+}
+
+// ListWallets отдает кошельки. Параметры: owner_id, status, currency,
+// min_balance и max_balance (включительно), created_from и created_to
+// (RFC3339, created_to не включается), sort (wallet_id, balance,
+// created_at), order (asc, desc), limit, cursor (next_cursor предыдущей
+// страницы, с теми же sort и order). Клиент, ограниченный владельцем,
+// видит только свои кошельки.
+func ListWallets(log *slog.Logger, lister WalletLister) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.lister.ListWallets"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		filter, err := parseFilter(r)
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		if owner := auth.OwnerScope(r.Context()); owner != "" {
+			if filter.OwnerID != "" && filter.OwnerID != owner {
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", errors.New("owner_id outside of client scope"))
+				return
+			}
+			filter.OwnerID = owner
+		}
+
+		limit := filter.Limit
+		// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+		filter.Limit++
+
+		wallets, err := lister.ListWallets(r.Context(), filter)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to list wallets", err)
+			return
+		}
+
+		var nextCursor string
+		if len(wallets) > limit {
+			wallets = wallets[:limit]
+			last := wallets[limit-1]
+			nextCursor = encodeCursor(cursor{
+				Sort:      filter.Sort,
+				Desc:      filter.Desc,
+				WalletID:  last.WalletID,
+				Balance:   last.Balance,
+				CreatedAt: last.CreatedAt,
+			})
+		}
+
+		result := make([]Wallet, 0, len(wallets))
+		for _, wallet := range wallets {
+			result = append(result, newWallet(wallet))
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Wallets: result, NextCursor: nextCursor})
+	}
+}
+
+func newWallet(wallet postgresql.Wallet) Wallet {
+	return Wallet{
+		WalletID:    wallet.WalletID,
+		Balance:     wallet.Balance,
+		Available:   wallet.Available(),
+		CreditLimit: wallet.CreditLimit,
+		Currency:    wallet.Currency,
+		Amount:      currency.Format(wallet.Balance, wallet.Currency),
+		OwnerID:     wallet.OwnerID,
+		Status:      wallet.Status,
+		Tier:        wallet.Tier,
+		CreatedAt:   wallet.CreatedAt,
+	}
+}
+
+func parseFilter(r *http.Request) (postgresql.WalletFilter, error) {
+	query := r.URL.Query()
+
+	filter := postgresql.WalletFilter{
+		OwnerID: query.Get("owner_id"),
+		Sort:    postgresql.WalletSortID,
+		Limit:   defaultLimit,
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("status"); v != "" {
+		filter.Status = strings.ToUpper(v)
+		if !statuses[filter.Status] {
+			return filter, fmt.Errorf("unsupported status %q", v)
+		}
+	}
+
+	if v := query.Get("currency"); v != "" {
+		filter.Currency = strings.ToUpper(v)
+		if !currency.IsSupported(filter.Currency) {
+			return filter, fmt.Errorf("unsupported currency %q", v)
+		}
+	}
+
+	var err error
+	if filter.MinBalance, err = parseBalance(query.Get("min_balance")); err != nil {
+		return filter, errors.New("min_balance must be an integer")
+	}
+	if filter.MaxBalance, err = parseBalance(query.Get("max_balance")); err != nil {
+		return filter, errors.New("max_balance must be an integer")
+	}
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return filter, errors.New("min_balance must not exceed max_balance")
+	}
+
+	if filter.CreatedFrom, err = parseTime(query.Get("created_from")); err != nil {
+		return filter, errors.New("created_from must be RFC3339")
+	}
+	if filter.CreatedTo, err = parseTime(query.Get("created_to")); err != nil {
+		return filter, errors.New("created_to must be RFC3339")
+	}
+
+	if v := query.Get("sort"); v != "" {
+		if !sortKeys[v] {
+			return filter, fmt.Errorf("unsupported sort %q", v)
+		}
+		filter.Sort = v
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		if c.Sort != filter.Sort || c.Desc != filter.Desc {
+			return filter, errors.New("cursor does not match sort and order")
+		}
+		filter.After = &postgresql.WalletCursor{WalletID: c.WalletID, Balance: c.Balance, CreatedAt: c.CreatedAt}
+	}
+
+	return filter, nil
+}
+
+func parseBalance(v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(v string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return cursor{}, err
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return cursor{}, err
+	}
+	if c.WalletID == uuid.Nil || !sortKeys[c.Sort] {
+		return cursor{}, errors.New("invalid cursor")
+	}
+	return c, nil
+}
@@ -0,0 +1,192 @@
+package lister
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/internal/lib/api/response"
+	"wallet/storage/postgresql"
+)
+
+type MockLister struct {
+	mock.Mock
+}
+
+func (m *MockLister) ListWallets(ctx context.Context, filter postgresql.WalletFilter) ([]postgresql.Wallet, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]postgresql.Wallet), args.Error(1)
+}
+
+func newRouter(lister WalletLister) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/api/v1/wallets", ListWallets(nil, lister))
+	return r
+}
+
+func TestListWallets(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	wallets := []postgresql.Wallet{
+		{WalletID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Balance: 500, Currency: "USD", Status: "ACTIVE", CreatedAt: created},
+		{WalletID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Balance: 300, Currency: "USD", Status: "ACTIVE", CreatedAt: created},
+		{WalletID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Balance: 100, Currency: "USD", Status: "ACTIVE", CreatedAt: created},
+	}
+
+	min, max := int64(100), int64(1000)
+	cursorAfterSecond := encodeCursor(cursor{Sort: postgresql.WalletSortBalance, Desc: true, WalletID: wallets[1].WalletID, Balance: 300, CreatedAt: created})
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(m *MockLister)
+		expectedStatus int
+		expectedError  string
+		expectedCount  int
+		expectedCursor string
+	}{
+		{
+			name:  "first page",
+			query: "?status=active&currency=usd&min_balance=100&max_balance=1000&sort=balance&order=desc&limit=2",
+			mockSetup: func(m *MockLister) {
+				m.On("ListWallets", postgresql.WalletFilter{
+					Status:     "ACTIVE",
+					Currency:   "USD",
+					MinBalance: &min,
+					MaxBalance: &max,
+					Sort:       postgresql.WalletSortBalance,
+					Desc:       true,
+					Limit:      3,
+				}).Return(wallets, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+			expectedCursor: cursorAfterSecond,
+		},
+		{
+			name:  "last page",
+			query: "?sort=balance&order=desc&limit=2&cursor=" + cursorAfterSecond,
+			mockSetup: func(m *MockLister) {
+				m.On("ListWallets", postgresql.WalletFilter{
+					Sort:  postgresql.WalletSortBalance,
+					Desc:  true,
+					After: &postgresql.WalletCursor{WalletID: wallets[1].WalletID, Balance: 300, CreatedAt: created},
+					Limit: 3,
+				}).Return(wallets[2:], nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:  "created range",
+			query: "?created_from=2026-03-01T00:00:00Z&created_to=2026-04-01T00:00:00Z&sort=created_at",
+			mockSetup: func(m *MockLister) {
+				m.On("ListWallets", postgresql.WalletFilter{
+					CreatedFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+					CreatedTo:   time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+					Sort:        postgresql.WalletSortCreatedAt,
+					Limit:       defaultLimit + 1,
+				}).Return(wallets, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
+		{
+			name:           "cursor from another sort",
+			query:          "?sort=created_at&cursor=" + cursorAfterSecond,
+			mockSetup:      func(m *MockLister) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "cursor does not match sort and order",
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=not-a-cursor!",
+			mockSetup:      func(m *MockLister) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid cursor",
+		},
+		{
+			name:           "unsupported sort",
+			query:          "?sort=owner_id",
+			mockSetup:      func(m *MockLister) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `unsupported sort "owner_id"`,
+		},
+		{
+			name:           "balance range reversed",
+			query:          "?min_balance=500&max_balance=100",
+			mockSetup:      func(m *MockLister) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "min_balance must not exceed max_balance",
+		},
+		{
+			name:           "invalid status",
+			query:          "?status=DELETED",
+			mockSetup:      func(m *MockLister) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `unsupported status "DELETED"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := new(MockLister)
+			tt.mockSetup(lister)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			newRouter(lister).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedError != "" {
+				var res response.Response
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				assert.Equal(t, tt.expectedError, res.Error)
+				return
+			}
+
+			var res Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Len(t, res.Wallets, tt.expectedCount)
+			assert.Equal(t, tt.expectedCursor, res.NextCursor)
+			lister.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListWalletsOwnerScope(t *testing.T) {
+	principal, err := auth.NewPrincipal("alice", "read")
+	require.NoError(t, err)
+
+	lister := new(MockLister)
+	lister.On("ListWallets", postgresql.WalletFilter{
+		OwnerID: "alice",
+		Sort:    postgresql.WalletSortID,
+		Limit:   defaultLimit + 1,
+	}).Return([]postgresql.Wallet{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	rec := httptest.NewRecorder()
+	newRouter(lister).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	lister.AssertExpectations(t)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/wallets?owner_id=bob", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	rec = httptest.NewRecorder()
+	newRouter(lister).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
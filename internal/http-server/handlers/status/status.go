@@ -0,0 +1,175 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+type StatusChanger interface {
+	ChangeWalletStatus(ctx context.Context, walletID uuid.UUID, status string, change postgresql.StatusChange) (postgresql.Wallet, error)
+	ListStatusChanges(ctx context.Context, walletID uuid.UUID) ([]postgresql.StatusChange, error)
+}
+
+// anonymousActor записывается в журнал, когда аутентификация отключена и
+// actor не передан.
+const anonymousActor = "anonymous"
+
+// Request — Actor учитывается только без аутентификации, иначе им
+// становится client_id администратора.
+type Request struct {
+	Status string `json:"status" validate:"required,oneof=ACTIVE FROZEN CLOSED"`
+	Reason string `json:"reason" validate:"required,max=1000"`
+	Actor  string `json:"actor,omitempty" validate:"max=255"`
+}
+
+type Change struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	RequestID  string    `json:"request_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type HistoryResponse struct {
+	resp.Response
+	WalletID uuid.UUID `json:"wallet_id"`
+	Changes  []Change  `json:"changes"`
+}
+
+func ChangeStatus(log *slog.Logger, changer StatusChanger) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.status.ChangeStatus"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		actor := req.Actor
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			actor = principal.ClientID
+		}
+		if actor == "" {
+			actor = anonymousActor
+		}
+
+		wallet, err := changer.ChangeWalletStatus(r.Context(), walletID, req.Status, postgresql.StatusChange{
+			Actor:     actor,
+			Reason:    req.Reason,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("wallet status changed",
+			slog.String("wallet_id", walletID.String()),
+			slog.String("status", wallet.Status),
+			slog.String("actor", actor),
+		)
+
+		render.JSON(w, r, getter.NewResponse(wallet))
+	}
+}
+
+func FetchHistory(log *slog.Logger, changer StatusChanger) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.status.FetchHistory"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		changes, err := changer.ListStatusChanges(r.Context(), walletID)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		res := HistoryResponse{
+			Response: resp.OK(),
+			WalletID: walletID,
+			Changes:  make([]Change, 0, len(changes)),
+		}
+		for _, c := range changes {
+			res.Changes = append(res.Changes, Change{
+				FromStatus: c.FromStatus,
+				ToStatus:   c.ToStatus,
+				Actor:      c.Actor,
+				Reason:     c.Reason,
+				RequestID:  c.RequestID,
+				CreatedAt:  c.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, res)
+	}
+}
+
+func sendError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if sender.SendContextError(w, r, log, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, storage.ErrWalletNotFound):
+		sender.SendError(w, r, log, http.StatusNotFound, "walletId not found", err)
+	case errors.Is(err, storage.ErrInvalidStatusTransition):
+		sender.SendError(w, r, log, http.StatusConflict, "status transition not allowed", err)
+	case errors.Is(err, storage.ErrWalletNotEmpty):
+		sender.SendError(w, r, log, http.StatusConflict, "wallet balance is not zero", err)
+	default:
+		sender.SendError(w, r, log, http.StatusInternalServerError, "failed to change status", err)
+	}
+}
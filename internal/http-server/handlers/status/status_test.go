@@ -0,0 +1,169 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatusChanger struct {
+	mock.Mock
+}
+
+func (m *MockStatusChanger) ChangeWalletStatus(ctx context.Context, walletID uuid.UUID, status string, change postgresql.StatusChange) (postgresql.Wallet, error) {
+	args := m.Called(walletID, status, change)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+func (m *MockStatusChanger) ListStatusChanges(ctx context.Context, walletID uuid.UUID) ([]postgresql.StatusChange, error) {
+	args := m.Called(walletID)
+	return args.Get(0).([]postgresql.StatusChange), args.Error(1)
+}
+
+var walletID = uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+func newRouter(changer StatusChanger) *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/api/v1/admin/wallets/{WALLET_UUID}/status", ChangeStatus(nil, changer))
+	r.Get("/api/v1/admin/wallets/{WALLET_UUID}/status/history", FetchHistory(nil, changer))
+	return r
+}
+
+func TestChangeStatus(t *testing.T) {
+	admin, err := auth.NewPrincipal("compliance", "admin")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		body           string
+		principal      *auth.Principal
+		mockSetup      func(m *MockStatusChanger)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:      "freeze by admin",
+			body:      `{"status": "FROZEN", "reason": "AML check", "actor": "ignored"}`,
+			principal: &admin,
+			mockSetup: func(m *MockStatusChanger) {
+				m.On("ChangeWalletStatus", walletID, postgresql.WalletFrozen, mock.MatchedBy(func(c postgresql.StatusChange) bool {
+					return c.Actor == "compliance" && c.Reason == "AML check"
+				})).Return(postgresql.Wallet{WalletID: walletID, Currency: "USD", Status: postgresql.WalletFrozen}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "actor from body without auth",
+			body: `{"status": "ACTIVE", "reason": "cleared", "actor": "ops"}`,
+			mockSetup: func(m *MockStatusChanger) {
+				m.On("ChangeWalletStatus", walletID, postgresql.WalletActive, mock.MatchedBy(func(c postgresql.StatusChange) bool {
+					return c.Actor == "ops"
+				})).Return(postgresql.Wallet{WalletID: walletID, Currency: "USD", Status: postgresql.WalletActive}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing reason",
+			body:           `{"status": "FROZEN"}`,
+			mockSetup:      func(m *MockStatusChanger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field Reason is a required field",
+		},
+		{
+			name:           "unknown status",
+			body:           `{"status": "DELETED", "reason": "x"}`,
+			mockSetup:      func(m *MockStatusChanger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field Status is not valid",
+		},
+		{
+			name: "close with balance",
+			body: `{"status": "CLOSED", "reason": "customer request"}`,
+			mockSetup: func(m *MockStatusChanger) {
+				m.On("ChangeWalletStatus", walletID, postgresql.WalletClosed, mock.Anything).
+					Return(postgresql.Wallet{}, fmt.Errorf("storage: %w", storage.ErrWalletNotEmpty))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "wallet balance is not zero",
+		},
+		{
+			name: "reopen closed wallet",
+			body: `{"status": "ACTIVE", "reason": "mistake"}`,
+			mockSetup: func(m *MockStatusChanger) {
+				m.On("ChangeWalletStatus", walletID, postgresql.WalletActive, mock.Anything).
+					Return(postgresql.Wallet{}, fmt.Errorf("storage: %w", storage.ErrInvalidStatusTransition))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "status transition not allowed",
+		},
+		{
+			name: "wallet not found",
+			body: `{"status": "FROZEN", "reason": "AML check"}`,
+			mockSetup: func(m *MockStatusChanger) {
+				m.On("ChangeWalletStatus", walletID, postgresql.WalletFrozen, mock.Anything).
+					Return(postgresql.Wallet{}, storage.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "walletId not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changer := new(MockStatusChanger)
+			tt.mockSetup(changer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+walletID.String()+"/status", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), *tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			newRouter(changer).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res response.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			changer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFetchHistory(t *testing.T) {
+	changer := new(MockStatusChanger)
+	changer.On("ListStatusChanges", walletID).Return([]postgresql.StatusChange{
+		{WalletID: walletID, FromStatus: postgresql.WalletActive, ToStatus: postgresql.WalletFrozen, Actor: "compliance", Reason: "AML check", CreatedAt: time.Now()},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+walletID.String()+"/status/history", nil)
+	rec := httptest.NewRecorder()
+
+	newRouter(changer).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res HistoryResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	if assert.Len(t, res.Changes, 1) {
+		assert.Equal(t, "compliance", res.Changes[0].Actor)
+		assert.Equal(t, postgresql.WalletFrozen, res.Changes[0].ToStatus)
+	}
+}
@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"wallet/internal/broadcast"
+	"wallet/internal/http-server/handlers/getter"
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+// События SSE: snapshot — текущее состояние кошелька при подключении,
+// balance — изменение баланса, reset — поток закрыт из-за отставания
+// клиента, нужно переподключиться.
+const (
+	EventSnapshot = "snapshot"
+	EventBalance  = "balance"
+	EventReset    = "reset"
+)
+
+// retryMillis — пауза перед переподключением, которую советуем EventSource.
+const retryMillis = 3000
+
+type WalletGetter interface {
+	GetWallet(ctx context.Context, walletID uuid.UUID) (postgresql.Wallet, error)
+}
+
+type Subscriber interface {
+	Subscribe(walletID uuid.UUID) (*broadcast.Subscription, error)
+}
+
+// BalanceStream держит SSE-поток изменений баланса кошелька, пока клиент
+// не отключится или сервис не начнет остановку.
+func BalanceStream(log *slog.Logger, wallets WalletGetter, hub Subscriber, heartbeat time.Duration) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.stream.BalanceStream"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		walletID, err := uuid.Parse(chi.URLParam(r, "WALLET_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		// Подписываемся до чтения кошелька, чтобы не пропустить изменения
+		// между снимком и первым событием.
+		sub, err := hub.Subscribe(walletID)
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusServiceUnavailable, "service is shutting down", err)
+			return
+		}
+		defer sub.Close()
+
+		wallet, err := wallets.GetWallet(r.Context(), walletID)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			if errors.Is(err, storage.ErrWalletNotFound) {
+				sender.SendError(w, r, log, http.StatusNotFound, "wallet does not exist", err)
+				return
+			}
+			sender.SendError(w, r, log, http.StatusInternalServerError, "failed to fetch wallet", err)
+			return
+		}
+
+		if owner := auth.OwnerScope(r.Context()); owner != "" && wallet.OwnerID != owner {
+			sender.SendError(w, r, log, http.StatusForbidden, "access denied", storage.ErrForbidden)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// Поток живет дольше SERVER_TIMEOUT.
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Warn("failed to reset write deadline", sl.Err(err))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", retryMillis); err != nil {
+			return
+		}
+		if err := writeEvent(w, "", EventSnapshot, getter.NewResponse(wallet)); err != nil {
+			log.Error("failed to write snapshot", sl.Err(err))
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			log.Error("streaming is not supported", sl.Err(err))
+			return
+		}
+
+		log.Info("balance stream opened", slog.String("wallet_id", walletID.String()))
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Info("balance stream closed by client", slog.String("wallet_id", walletID.String()))
+				return
+
+			case <-ticker.C:
+				if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+					return
+				}
+
+			case event, ok := <-sub.Events():
+				if !ok {
+					if sub.Lagged() {
+						log.Warn("balance stream reset", slog.String("wallet_id", walletID.String()))
+						_ = writeEvent(w, "", EventReset, struct{}{})
+						_ = rc.Flush()
+					}
+					return
+				}
+				if err := writeEvent(w, event.EventID.String(), EventBalance, event); err != nil {
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, id, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
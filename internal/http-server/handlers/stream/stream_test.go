@@ -0,0 +1,162 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wallet/internal/broadcast"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockGetterWallet struct {
+	mock.Mock
+}
+
+func (m *MockGetterWallet) GetWallet(ctx context.Context, walletID uuid.UUID) (postgresql.Wallet, error) {
+	args := m.Called(walletID)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+var walletID = uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+func newServer(wallets *MockGetterWallet, hub *broadcast.Hub) *httptest.Server {
+	r := chi.NewRouter()
+	r.Get("/api/v1/wallets/{WALLET_UUID}/stream", BalanceStream(nil, wallets, hub, time.Hour))
+	return httptest.NewServer(r)
+}
+
+// readEvent читает одно SSE-событие (до пустой строки), пропуская
+// служебные строки retry.
+func readEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+
+	event := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(event) == 0 {
+				continue
+			}
+			return event
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		if key == "retry" {
+			continue
+		}
+		event[key] = value
+	}
+}
+
+func TestBalanceStream(t *testing.T) {
+	wallets := new(MockGetterWallet)
+	wallets.On("GetWallet", walletID).Return(postgresql.Wallet{WalletID: walletID, Balance: 100, Currency: "USD"}, nil)
+
+	hub := broadcast.NewHub(8)
+	srv := newServer(wallets, hub)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/v1/wallets/" + walletID.String() + "/stream")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(res.Body)
+
+	snapshot := readEvent(t, body)
+	assert.Equal(t, EventSnapshot, snapshot["event"])
+	assert.Contains(t, snapshot["data"], `"balance":100`)
+
+	eventID := uuid.New()
+	hub.Publish(postgresql.BalanceEvent{EventID: eventID, WalletID: walletID, Balance: 150, Amount: 50})
+
+	balance := readEvent(t, body)
+	assert.Equal(t, EventBalance, balance["event"])
+	assert.Equal(t, eventID.String(), balance["id"])
+	assert.Contains(t, balance["data"], `"balance":150`)
+
+	// Остановка сервиса закрывает поток.
+	hub.Close()
+	_, err = body.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestBalanceStreamReset(t *testing.T) {
+	wallets := new(MockGetterWallet)
+	wallets.On("GetWallet", walletID).Return(postgresql.Wallet{WalletID: walletID, Currency: "USD"}, nil)
+
+	hub := broadcast.NewHub(8)
+	srv := newServer(wallets, hub)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/v1/wallets/" + walletID.String() + "/stream")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+	readEvent(t, body)
+
+	hub.Reset()
+
+	reset := readEvent(t, body)
+	assert.Equal(t, EventReset, reset["event"])
+}
+
+func TestBalanceStreamUnsubscribesOnDisconnect(t *testing.T) {
+	wallets := new(MockGetterWallet)
+	wallets.On("GetWallet", walletID).Return(postgresql.Wallet{WalletID: walletID, Currency: "USD"}, nil)
+
+	hub := broadcast.NewHub(8)
+	srv := newServer(wallets, hub)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/v1/wallets/" + walletID.String() + "/stream")
+	require.NoError(t, err)
+
+	readEvent(t, bufio.NewReader(res.Body))
+	assert.Equal(t, 1, hub.Subscribers())
+
+	res.Body.Close()
+
+	assert.Eventually(t, func() bool { return hub.Subscribers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestBalanceStreamErrors(t *testing.T) {
+	wallets := new(MockGetterWallet)
+	wallets.On("GetWallet", walletID).Return(postgresql.Wallet{}, storage.ErrWalletNotFound)
+
+	hub := broadcast.NewHub(8)
+
+	r := chi.NewRouter()
+	r.Get("/api/v1/wallets/{WALLET_UUID}/stream", BalanceStream(nil, wallets, hub, time.Hour))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/stream", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, 0, hub.Subscribers())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/not-a-uuid/stream", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	hub.Close()
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/stream", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,289 @@
+package transaction
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Batcher interface {
+	Operation
+	ApplyBatch(ctx context.Context, items []postgresql.BatchItem, meta postgresql.OperationMeta) ([]postgresql.OperationResult, error)
+}
+
+const (
+	// ModeAtomic — все элементы в одной транзакции БД: либо все, либо ничего.
+	ModeAtomic = "atomic"
+	// ModeBestEffort — каждый элемент выполняется отдельно, результат по каждому.
+	ModeBestEffort = "best_effort"
+)
+
+type BatchRequest struct {
+	Mode           string    `json:"mode" validate:"required,oneof=atomic best_effort"`
+	Items          []Request `json:"items" validate:"required,min=1,dive"`
+	IdempotencyKey string    `json:"idempotencyKey,omitempty" validate:"max=200"`
+}
+
+type ItemResult struct {
+	Index    int       `json:"index"`
+	Status   string    `json:"status"`
+	Code     int       `json:"code"`
+	Error    string    `json:"error,omitempty"`
+	WalletID uuid.UUID `json:"walletId"`
+	Balance  int64     `json:"balance"`
+	Currency string    `json:"currency,omitempty"`
+	Amount   int64     `json:"amount"`
+	Fee      int64     `json:"fee"`
+	Total    int64     `json:"total"`
+
+	Limit *LimitDetails `json:"limit,omitempty"`
+}
+
+type BatchResponse struct {
+	resp.Response
+	Mode        string       `json:"mode"`
+	Succeeded   int          `json:"succeeded"`
+	Failed      int          `json:"failed"`
+	FailedIndex *int         `json:"failed_index,omitempty"`
+	Results     []ItemResult `json:"results"`
+}
+
+func WalletBatch(log *slog.Logger, batcher Batcher, maxItems int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.transaction.WalletBatch"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req BatchRequest
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		log.Info("request body decoded", slog.String("mode", req.Mode), slog.Int("items", len(req.Items)))
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		if maxItems > 0 && len(req.Items) > maxItems {
+			sender.SendError(w, r, log, http.StatusBadRequest, fmt.Sprintf("too many items, max %d", maxItems), fmt.Errorf("batch of %d items", len(req.Items)))
+			return
+		}
+
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+				sender.SendError(w, r, log, http.StatusBadRequest, "idempotency key mismatch", errors.New("header and body idempotency keys differ"))
+				return
+			}
+			req.IdempotencyKey = key
+		}
+		if SendReservedKeyError(w, r, log, req.IdempotencyKey) {
+			return
+		}
+
+		// Весь пакет проверяется до выполнения: в атомарном режиме нельзя
+		// узнать об ошибке посередине, а в best_effort это просто дешевле.
+		for i, item := range req.Items {
+			perm, ok := operationPermissions[item.Operation]
+			if !ok {
+				sender.SendError(w, r, log, http.StatusBadRequest, fmt.Sprintf("item %d: unsupported operation", i), errors.New("unsupported operation"))
+				return
+			}
+			if err := auth.Check(r.Context(), perm); err != nil {
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", err)
+				return
+			}
+			if req.Mode == ModeAtomic && item.IdempotencyKey != "" {
+				sender.SendError(w, r, log, http.StatusBadRequest, fmt.Sprintf("item %d: idempotency key is set per batch in atomic mode", i), errors.New("item idempotency key in atomic batch"))
+				return
+			}
+			if SendReservedKeyError(w, r, log, item.IdempotencyKey) {
+				return
+			}
+		}
+
+		if req.Mode == ModeAtomic {
+			applyAtomic(w, r, log, batcher, req)
+			return
+		}
+		applyBestEffort(w, r, log, batcher, req)
+	}
+}
+
+func applyAtomic(w http.ResponseWriter, r *http.Request, log *slog.Logger, batcher Batcher, req BatchRequest) {
+	items := make([]postgresql.BatchItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, postgresql.BatchItem{
+			WalletID: item.WalletID,
+			Type:     item.Operation,
+			Amount:   item.Amount,
+			Currency: item.Currency,
+		})
+	}
+
+	meta := postgresql.OperationMeta{
+		RequestID:      middleware.GetReqID(r.Context()),
+		IdempotencyKey: req.IdempotencyKey,
+		Fingerprint:    batchFingerprint(req.Items),
+		OwnerID:        auth.OwnerScope(r.Context()),
+	}
+
+	applied, err := batcher.ApplyBatch(r.Context(), items, meta)
+	if err != nil {
+		var itemErr *postgresql.BatchItemError
+		if !errors.As(err, &itemErr) || itemErr.Index >= len(req.Items) {
+			SendOperationError(w, r, log, err)
+			return
+		}
+
+		log.Error("batch rolled back", slog.Int("failed_index", itemErr.Index), sl.Err(err))
+
+		index := itemErr.Index
+		result := failedResult(index, req.Items[index], err)
+		w.WriteHeader(result.Code)
+		render.JSON(w, r, BatchResponse{
+			Response:    resp.Error(result.Error),
+			Mode:        ModeAtomic,
+			Failed:      1,
+			FailedIndex: &index,
+			Results:     []ItemResult{result},
+		})
+		return
+	}
+
+	results := make([]ItemResult, 0, len(applied))
+	for i, res := range applied {
+		results = append(results, okResult(i, req.Items[i], res))
+	}
+
+	log.Info("batch applied", slog.Int("items", len(results)))
+
+	render.JSON(w, r, BatchResponse{
+		Response:  resp.OK(),
+		Mode:      ModeAtomic,
+		Succeeded: len(results),
+		Results:   results,
+	})
+}
+
+// batchItemKeyPrefix — префикс ключей, которые best_effort выводит для
+// элементов без своего ключа. Клиентские ключи с ним отклоняются, иначе
+// отдельный запрос мог бы совпасть с элементом пакета.
+const batchItemKeyPrefix = "batch:"
+
+// itemKey — ключ элемента i пакета с ключом batchKey. Хеш держит длину
+// ключа постоянной при любом ключе пакета.
+func itemKey(batchKey string, i int) string {
+	sum := sha256.Sum256([]byte(batchKey))
+	return fmt.Sprintf("%s%s/%d", batchItemKeyPrefix, hex.EncodeToString(sum[:]), i)
+}
+
+// applyBestEffort выполняет элементы по очереди, каждый в своей транзакции.
+// Ключ идемпотентности пакета превращается в ключи элементов (см. itemKey),
+// так что повтор всего пакета не применит уже прошедшие элементы повторно.
+func applyBestEffort(w http.ResponseWriter, r *http.Request, log *slog.Logger, operation Operation, req BatchRequest) {
+	res := BatchResponse{
+		Response: resp.OK(),
+		Mode:     ModeBestEffort,
+		Results:  make([]ItemResult, 0, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		key := item.IdempotencyKey
+		if key == "" && req.IdempotencyKey != "" {
+			key = itemKey(req.IdempotencyKey, i)
+		}
+
+		meta := postgresql.OperationMeta{
+			RequestID:      middleware.GetReqID(r.Context()),
+			IdempotencyKey: key,
+			Fingerprint:    fingerprint(item),
+			OwnerID:        auth.OwnerScope(r.Context()),
+		}
+
+		var (
+			result postgresql.OperationResult
+			err    error
+		)
+		if item.Operation == postgresql.OperationDeposit {
+			result, err = operation.DepositWallet(r.Context(), item.WalletID, item.Amount, item.Currency, meta)
+		} else {
+			result, err = operation.WithdrawWallet(r.Context(), item.WalletID, item.Amount, item.Currency, meta)
+		}
+
+		if err != nil {
+			log.Error("batch item failed", slog.Int("index", i), sl.Err(err))
+			res.Results = append(res.Results, failedResult(i, item, err))
+			res.Failed++
+			continue
+		}
+
+		res.Results = append(res.Results, okResult(i, item, result))
+		res.Succeeded++
+	}
+
+	log.Info("batch processed", slog.Int("succeeded", res.Succeeded), slog.Int("failed", res.Failed))
+
+	render.JSON(w, r, res)
+}
+
+func okResult(index int, item Request, res postgresql.OperationResult) ItemResult {
+	return ItemResult{
+		Index:    index,
+		Status:   resp.StatusOK,
+		Code:     http.StatusOK,
+		WalletID: res.WalletID,
+		Balance:  res.Balance,
+		Currency: res.Currency,
+		Amount:   item.Amount,
+		Fee:      res.Fee,
+		Total:    total(item.Operation, item.Amount, res.Fee),
+	}
+}
+
+func failedResult(index int, item Request, err error) ItemResult {
+	status, message := errorStatus(err)
+	return ItemResult{
+		Index:    index,
+		Status:   resp.StatusError,
+		Code:     status,
+		Error:    message,
+		WalletID: item.WalletID,
+		Limit:    limitDetails(err),
+	}
+}
+
+// batchFingerprint — отпечаток атомарного пакета: порядок элементов важен.
+func batchFingerprint(items []Request) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, fingerprint(item))
+	}
+	sum := sha256.Sum256([]byte("batch|" + strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,230 @@
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wallet/internal/http-server/middleware/auth"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBatcher — мок реализация интерфейса Batcher
+type MockBatcher struct {
+	MockOperation
+}
+
+func (m *MockBatcher) ApplyBatch(ctx context.Context, items []postgresql.BatchItem, meta postgresql.OperationMeta) ([]postgresql.OperationResult, error) {
+	args := m.Called(items, meta)
+	return args.Get(0).([]postgresql.OperationResult), args.Error(1)
+}
+
+func sendBatch(t *testing.T, batcher Batcher, body map[string]interface{}, ctx context.Context) (*httptest.ResponseRecorder, BatchResponse) {
+	t.Helper()
+
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/v1/wallet/batch", bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	rec := httptest.NewRecorder()
+
+	WalletBatch(slog.Default(), batcher, 3).ServeHTTP(rec, req)
+
+	var res BatchResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &res)
+	return rec, res
+}
+
+func batchItem(walletID uuid.UUID, operation string, amount int64) map[string]interface{} {
+	return map[string]interface{}{
+		"valletId":      walletID.String(),
+		"operationType": operation,
+		"amount":        amount,
+		"currency":      "USD",
+	}
+}
+
+func TestWalletBatchAtomic(t *testing.T) {
+	first := uuid.New()
+	second := uuid.New()
+
+	items := []postgresql.BatchItem{
+		{WalletID: first, Type: "DEPOSIT", Amount: 100, Currency: "USD"},
+		{WalletID: second, Type: "WITHDRAW", Amount: 40, Currency: "USD"},
+	}
+
+	t.Run("all applied", func(t *testing.T) {
+		batcher := new(MockBatcher)
+		batcher.On("ApplyBatch", items, mock.MatchedBy(func(meta postgresql.OperationMeta) bool {
+			return meta.IdempotencyKey == "payout-1" && meta.Fingerprint != ""
+		})).Return([]postgresql.OperationResult{
+			{Wallet: postgresql.Wallet{WalletID: first, Balance: 100, Currency: "USD"}},
+			{Wallet: postgresql.Wallet{WalletID: second, Balance: 60, Currency: "USD"}, Fee: 2},
+		}, nil)
+
+		rec, res := sendBatch(t, batcher, map[string]interface{}{
+			"mode":           ModeAtomic,
+			"idempotencyKey": "payout-1",
+			"items":          []interface{}{batchItem(first, "DEPOSIT", 100), batchItem(second, "WITHDRAW", 40)},
+		}, nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 2, res.Succeeded)
+		assert.Nil(t, res.FailedIndex)
+		assert.Equal(t, int64(60), res.Results[1].Balance)
+		assert.Equal(t, int64(2), res.Results[1].Fee)
+		assert.Equal(t, int64(42), res.Results[1].Total)
+		batcher.AssertExpectations(t)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		batcher := new(MockBatcher)
+		batcher.On("ApplyBatch", items, mock.Anything).
+			Return([]postgresql.OperationResult(nil), &postgresql.BatchItemError{Index: 1, Err: storage.ErrInsufficientFunds})
+
+		rec, res := sendBatch(t, batcher, map[string]interface{}{
+			"mode":  ModeAtomic,
+			"items": []interface{}{batchItem(first, "DEPOSIT", 100), batchItem(second, "WITHDRAW", 40)},
+		}, nil)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "insufficient funds", res.Error)
+		if assert.NotNil(t, res.FailedIndex) {
+			assert.Equal(t, 1, *res.FailedIndex)
+		}
+		assert.Equal(t, second, res.Results[0].WalletID)
+	})
+
+	t.Run("reserved batch key", func(t *testing.T) {
+		rec, _ := sendBatch(t, new(MockBatcher), map[string]interface{}{
+			"mode":           ModeAtomic,
+			"idempotencyKey": "batch:payout",
+			"items":          []interface{}{batchItem(first, "DEPOSIT", 100)},
+		}, nil)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("item idempotency key", func(t *testing.T) {
+		item := batchItem(first, "DEPOSIT", 100)
+		item["idempotencyKey"] = "item-key"
+
+		rec, _ := sendBatch(t, new(MockBatcher), map[string]interface{}{
+			"mode":  ModeAtomic,
+			"items": []interface{}{item},
+		}, nil)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestWalletBatchBestEffort(t *testing.T) {
+	first := uuid.New()
+	second := uuid.New()
+	third := uuid.New()
+
+	batcher := new(MockBatcher)
+	batcher.On("DepositWallet", first, int64(100), "USD", mock.MatchedBy(func(meta postgresql.OperationMeta) bool {
+		return meta.IdempotencyKey == itemKey("payout-2", 0)
+	})).Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: first, Balance: 100, Currency: "USD"}}, nil)
+	batcher.On("WithdrawWallet", second, int64(40), "USD", mock.Anything).
+		Return(postgresql.OperationResult{}, storage.ErrWalletNotFound)
+	batcher.On("WithdrawWallet", third, int64(10), "USD", mock.Anything).
+		Return(postgresql.OperationResult{}, storage.ErrCurrencyMismatch)
+
+	rec, res := sendBatch(t, batcher, map[string]interface{}{
+		"mode":           ModeBestEffort,
+		"idempotencyKey": "payout-2",
+		"items": []interface{}{
+			batchItem(first, "DEPOSIT", 100),
+			batchItem(second, "WITHDRAW", 40),
+			batchItem(third, "WITHDRAW", 10),
+		},
+	}, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 2, res.Failed)
+	assert.Equal(t, http.StatusOK, res.Results[0].Code)
+	assert.Equal(t, http.StatusNotFound, res.Results[1].Code)
+	assert.Equal(t, "walletId not found", res.Results[1].Error)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Results[2].Code)
+	batcher.AssertExpectations(t)
+}
+
+func TestWalletBatchValidation(t *testing.T) {
+	walletID := uuid.New()
+
+	principal, err := auth.NewPrincipal("client-1", "deposit")
+	assert.NoError(t, err)
+	depositOnly := auth.WithPrincipal(context.Background(), principal)
+
+	tests := []struct {
+		name           string
+		body           map[string]interface{}
+		ctx            context.Context
+		expectedStatus int
+	}{
+		{
+			name:           "unknown mode",
+			body:           map[string]interface{}{"mode": "sometimes", "items": []interface{}{batchItem(walletID, "DEPOSIT", 1)}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty batch",
+			body:           map[string]interface{}{"mode": ModeAtomic, "items": []interface{}{}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "too many items",
+			body: map[string]interface{}{"mode": ModeBestEffort, "items": []interface{}{
+				batchItem(walletID, "DEPOSIT", 1), batchItem(walletID, "DEPOSIT", 1),
+				batchItem(walletID, "DEPOSIT", 1), batchItem(walletID, "DEPOSIT", 1),
+			}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported operation",
+			body:           map[string]interface{}{"mode": ModeBestEffort, "items": []interface{}{batchItem(walletID, "TRANSFER", 1)}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "item not permitted",
+			body:           map[string]interface{}{"mode": ModeBestEffort, "items": []interface{}{batchItem(walletID, "DEPOSIT", 1), batchItem(walletID, "WITHDRAW", 1)}},
+			ctx:            depositOnly,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batcher := new(MockBatcher)
+
+			rec, _ := sendBatch(t, batcher, tt.body, tt.ctx)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			batcher.AssertNotCalled(t, "ApplyBatch", mock.Anything, mock.Anything)
+			batcher.AssertNotCalled(t, "DepositWallet", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestItemKey(t *testing.T) {
+	// Клиентский ключ "abc/0" не совпадает с ключом элемента 0 пакета "abc".
+	assert.NotEqual(t, "abc/0", itemKey("abc", 0))
+	assert.NotEqual(t, itemKey("abc", 0), itemKey("abc", 1))
+	assert.NotEqual(t, itemKey("abc", 10), itemKey("abc/1", 0))
+	assert.True(t, strings.HasPrefix(itemKey("abc", 0), batchItemKeyPrefix))
+}
@@ -0,0 +1,109 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+// errorStatus — код ответа и сообщение для ошибки операции. Общие для
+// одиночной операции и элементов пакета.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, storage.ErrCanceled):
+		return sender.StatusClientClosedRequest, "request canceled"
+	case errors.Is(err, storage.ErrTimeout):
+		return http.StatusServiceUnavailable, "operation timed out"
+	case errors.Is(err, storage.ErrWalletNotFound):
+		return http.StatusNotFound, "walletId not found"
+	case errors.Is(err, storage.ErrInsufficientFunds):
+		return http.StatusNotFound, "insufficient funds"
+	case errors.Is(err, storage.ErrCurrencyMismatch):
+		return http.StatusUnprocessableEntity, "currency does not match wallet"
+	case errors.Is(err, storage.ErrIdempotencyConflict):
+		return http.StatusConflict, "idempotency key already used"
+	case errors.Is(err, storage.ErrForbidden):
+		return http.StatusForbidden, "access denied"
+	case errors.Is(err, storage.ErrWalletFrozen):
+		return http.StatusConflict, "wallet is frozen"
+	case errors.Is(err, storage.ErrWalletClosed):
+		return http.StatusConflict, "wallet is closed"
+	case errors.Is(err, storage.ErrLimitExceeded):
+		return http.StatusUnprocessableEntity, "limit exceeded"
+	case errors.Is(err, storage.ErrFeeExceedsAmount):
+		return http.StatusUnprocessableEntity, "amount does not cover fee"
+	default:
+		return http.StatusBadRequest, "operation failed"
+	}
+}
+
+// LimitDetails — сработавший лимит кошелька, см. postgresql.LimitError.
+type LimitDetails struct {
+	Name      string `json:"name"`
+	Max       int64  `json:"max"`
+	Attempted int64  `json:"attempted"`
+}
+
+type LimitResponse struct {
+	resp.Response
+	Limit LimitDetails `json:"limit"`
+}
+
+func limitDetails(err error) *LimitDetails {
+	var limitErr *postgresql.LimitError
+	if !errors.As(err, &limitErr) {
+		return nil
+	}
+	return &LimitDetails{Name: limitErr.Limit, Max: limitErr.Max, Attempted: limitErr.Attempted}
+}
+
+// SendLimitError отвечает 422 с описанием лимита, если err — превышение
+// лимита. Иначе ничего не пишет и возвращает false.
+func SendLimitError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	limit := limitDetails(err)
+	if limit == nil {
+		return false
+	}
+
+	log.Error("limit exceeded", sl.Err(err))
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	render.JSON(w, r, LimitResponse{
+		Response: resp.Error("limit exceeded"),
+		Limit:    *limit,
+	})
+	return true
+}
+
+// SendReservedKeyError отвечает 400, если клиентский ключ идемпотентности
+// занимает пространство ключей элементов пакета. Иначе ничего не пишет и
+// возвращает false.
+func SendReservedKeyError(w http.ResponseWriter, r *http.Request, log *slog.Logger, key string) bool {
+	if !strings.HasPrefix(key, batchItemKeyPrefix) {
+		return false
+	}
+	sender.SendError(w, r, log, http.StatusBadRequest,
+		fmt.Sprintf("idempotency key must not start with %q", batchItemKeyPrefix),
+		errors.New("reserved idempotency key prefix"))
+	return true
+}
+
+// SendOperationError отвечает на ошибку операции с балансом: 422 с
+// описанием лимита или код из errorStatus. Общий для всех обработчиков,
+// которые двигают деньги.
+func SendOperationError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if SendLimitError(w, r, log, err) {
+		return
+	}
+	status, message := errorStatus(err)
+	sender.SendError(w, r, log, status, message, err)
+}
@@ -1,13 +1,17 @@
 package transaction
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"wallet/internal/http-server/middleware/auth"
 	resp "wallet/internal/lib/api/response"
 	"wallet/internal/lib/api/sender"
 	"wallet/internal/lib/logger/sl"
-	"wallet/storage"
 	"wallet/storage/postgresql"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,20 +21,36 @@ import (
 )
 
 type Operation interface {
-	DepositWallet(walletID uuid.UUID, amount int64) (postgresql.Wallet, error)
-	WithdrawWallet(walletID uuid.UUID, amount int64) (postgresql.Wallet, error)
+	DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error)
+	WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error)
 }
 
 type Request struct {
 	WalletID     uuid.UUID `json:"valletId" validate:"required"`
 	Operation    string    `json:"operationType"` // можно было и так  validate:"required,oneof=DEPOSIT WITHDRAW", но я сделал слегка по другому))
-	Amount       int64     `json:"amount" validate:"required,min=1"`
+	Amount       int64     `json:"amount" validate:"required,min=1"` // в минимальных единицах валюты
+	Currency     string    `json:"currency" validate:"required,iso4217"`
+	IdempotencyKey string  `json:"idempotencyKey,omitempty" validate:"max=255"`
 }
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+var operationPermissions = map[string]auth.Permission{
+	"DEPOSIT":  auth.PermDeposit,
+	"WITHDRAW": auth.PermWithdraw,
+}
+
+// Response — Amount — сумма операции из запроса, Fee — комиссия за нее,
+// Total — на сколько фактически изменился баланс: Amount + Fee для
+// списания, Amount - Fee для пополнения.
 type Response struct {
 	resp.Response
 	WalletID uuid.UUID `json:"walletId"`
 	Balance  int64     `json:"balance"`
+	Currency string    `json:"currency"`
+	Amount   int64     `json:"amount"`
+	Fee      int64     `json:"fee"`
+	Total    int64     `json:"total"`
 }
 
 func WalletOperation(log *slog.Logger, operation Operation) http.HandlerFunc {
@@ -60,44 +80,50 @@ func WalletOperation(log *slog.Logger, operation Operation) http.HandlerFunc {
 			return
 		}
 
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+				sender.SendError(w, r, log, http.StatusBadRequest, "idempotency key mismatch", errors.New("header and body idempotency keys differ"))
+				return
+			}
+			req.IdempotencyKey = key
+		}
+		if SendReservedKeyError(w, r, log, req.IdempotencyKey) {
+			return
+		}
+
+		meta := postgresql.OperationMeta{
+			RequestID:      middleware.GetReqID(r.Context()),
+			IdempotencyKey: req.IdempotencyKey,
+			Fingerprint:    fingerprint(req),
+			OwnerID:        auth.OwnerScope(r.Context()),
+		}
+
+		// Права зависят от типа операции, поэтому проверяются здесь, а не на маршруте.
+		if perm, ok := operationPermissions[req.Operation]; ok {
+			if err := auth.Check(r.Context(), perm); err != nil {
+				sender.SendError(w, r, log, http.StatusForbidden, "access denied", err)
+				return
+			}
+		}
 
 		switch req.Operation {
 		case "DEPOSIT":
-			res, err := operation.DepositWallet(req.WalletID, req.Amount)
+			res, err := operation.DepositWallet(r.Context(), req.WalletID, req.Amount, req.Currency, meta)
 			if err != nil {
-				if errors.Is(err, storage.ErrWalletNotFound) {
-					sender.SendError(w, r, log, http.StatusNotFound, "walletId not found", err)
-					return
-				}
-				sender.SendError(w, r, log, http.StatusBadRequest, "operation failed", err)				
+				SendOperationError(w, r, log, err)
 				return
 			}
 			log.Info("wallet found, operation - WITHDRAW", slog.String("walletID", req.WalletID.String()))
-			render.JSON(w, r, Response{
-				Response: resp.OK(),
-				WalletID: res.WalletID,
-				Balance: int64(res.Balance),
-			})
+			render.JSON(w, r, newResponse(req, res))
 			return
 		case "WITHDRAW":
-			res, err := operation.WithdrawWallet(req.WalletID, req.Amount)
+			res, err := operation.WithdrawWallet(r.Context(), req.WalletID, req.Amount, req.Currency, meta)
 			if err != nil {
-				if errors.Is(err, storage.ErrWalletNotFound) {
-					sender.SendError(w, r, log, http.StatusNotFound, "walletId not found", err)
-					return
-				} else if errors.Is(err, storage.ErrInsufficientFunds) {
-					sender.SendError(w, r, log, http.StatusNotFound, "insufficient funds", err)
-					return
-				}
-				sender.SendError(w, r, log, http.StatusBadRequest, "operation failed", err)				
+				SendOperationError(w, r, log, err)
 				return
 			}
 			log.Info("wallet found, operation - WITHDRAW", slog.String("walletID", req.WalletID.String()))
-			render.JSON(w, r, Response{
-				Response: resp.OK(),
-				WalletID: res.WalletID,
-				Balance: int64(res.Balance),
-			})
+			render.JSON(w, r, newResponse(req, res))
 		default:
 			sender.SendError(w, r, log, http.StatusBadRequest, "unsupported operation", errors.New("unsupported operation"))
 			return
@@ -105,3 +131,29 @@ func WalletOperation(log *slog.Logger, operation Operation) http.HandlerFunc {
 	}
 }
 
+func newResponse(req Request, res postgresql.OperationResult) Response {
+	return Response{
+		Response: resp.OK(),
+		WalletID: res.WalletID,
+		Balance:  res.Balance,
+		Currency: res.Currency,
+		Amount:   req.Amount,
+		Fee:      res.Fee,
+		Total:    total(req.Operation, req.Amount, res.Fee),
+	}
+}
+
+// total — на сколько операция изменила баланс, без знака.
+func total(operation string, amount, fee int64) int64 {
+	if operation == postgresql.OperationDeposit {
+		return amount - fee
+	}
+	return amount + fee
+}
+
+// fingerprint описывает содержимое запроса без ключа идемпотентности:
+// повтор с тем же ключом, но другим телом, считается конфликтом.
+func fingerprint(req Request) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", req.Operation, req.WalletID, req.Amount, req.Currency)))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -10,7 +11,9 @@ import (
 	"sync"
 	"testing"
 
+	"wallet/internal/http-server/middleware/auth"
 	"wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
 	"wallet/storage"
 	"wallet/storage/postgresql"
 
@@ -26,14 +29,14 @@ type MockOperation struct {
 	mock.Mock
 }
 
-func (m *MockOperation) DepositWallet(walletID uuid.UUID, amount int64) (postgresql.Wallet, error) {
-	args := m.Called(walletID, amount)
-	return args.Get(0).(postgresql.Wallet), args.Error(1)
+func (m *MockOperation) DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	args := m.Called(walletID, amount, currency, meta)
+	return args.Get(0).(postgresql.OperationResult), args.Error(1)
 }
 
-func (m *MockOperation) WithdrawWallet(walletID uuid.UUID, amount int64) (postgresql.Wallet, error) {
-	args := m.Called(walletID, amount)
-	return args.Get(0).(postgresql.Wallet), args.Error(1)
+func (m *MockOperation) WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	args := m.Called(walletID, amount, currency, meta)
+	return args.Get(0).(postgresql.OperationResult), args.Error(1)
 }
 
 // TestWalletOperationConcurrent — тест с 1000 запросами
@@ -42,7 +45,7 @@ func TestWalletOperationConcurrent(t *testing.T) {
 
 	// Создаем тестовый кошелек
 	testWalletID := uuid.New()
-	mockOp.On("DepositWallet", testWalletID, int64(100)).Return(postgresql.Wallet{WalletID: testWalletID, Balance: 100}, nil)
+	mockOp.On("DepositWallet", testWalletID, int64(100), "USD", mock.Anything).Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: testWalletID, Balance: 100}}, nil)
 
 	logger := slog.Default()
 	handler := WalletOperation(logger, mockOp)
@@ -67,6 +70,7 @@ func TestWalletOperationConcurrent(t *testing.T) {
 				"valletId":      testWalletID,
 				"operationType": "DEPOSIT",
 				"amount":        100,
+				"currency":      "USD",
 			})
 
 			req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(reqBody))
@@ -107,6 +111,7 @@ func TestWalletOperationCases(t *testing.T) {
 				"valletId":      "f22bd5ed-9155-4ba0-90c4-4880912d7ad4",
 				"operationType": "DEPOSIT",
 				"amount":        100,
+				"currency":      "USD",
 			},
 			mockWallet: postgresql.Wallet{
 				WalletID: uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4"),
@@ -121,6 +126,7 @@ func TestWalletOperationCases(t *testing.T) {
 				"valletId":      "a45c73fd-3e36-466a-8e57-15e1cf0f35d2",
 				"operationType": "WITHDRAW",
 				"amount":        50,
+				"currency":      "USD",
 			},
 			mockWallet: postgresql.Wallet{
 				WalletID: uuid.MustParse("a45c73fd-3e36-466a-8e57-15e1cf0f35d2"),
@@ -135,6 +141,7 @@ func TestWalletOperationCases(t *testing.T) {
 				"valletId":      "b1234567-89ab-cdef-0123-456789abcdef",
 				"operationType": "WITHDRAW",
 				"amount":        100,
+				"currency":      "USD",
 			},
 			mockErr:        storage.ErrWalletNotFound,
 			expectedStatus: http.StatusNotFound,
@@ -146,11 +153,85 @@ func TestWalletOperationCases(t *testing.T) {
 				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
 				"operationType": "WITHDRAW",
 				"amount":        500,
+				"currency":      "USD",
 			},
 			mockErr:        storage.ErrInsufficientFunds,
 			expectedStatus: http.StatusNotFound,
 			expectedResp:   response.Response{Status: response.StatusError, Error: "insufficient funds"},
 		},
+		{
+			name: "currency mismatch",
+			requestBody: map[string]interface{}{
+				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType": "WITHDRAW",
+				"amount":        10,
+				"currency":      "USD",
+			},
+			mockErr:        storage.ErrCurrencyMismatch,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "currency does not match wallet"},
+		},
+		{
+			name: "idempotency key reused",
+			requestBody: map[string]interface{}{
+				"valletId":       "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType":  "WITHDRAW",
+				"amount":         10,
+				"currency":       "USD",
+				"idempotencyKey": "retry-1",
+			},
+			mockErr:        storage.ErrIdempotencyConflict,
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "idempotency key already used"},
+		},
+		{
+			name: "frozen wallet",
+			requestBody: map[string]interface{}{
+				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType": "WITHDRAW",
+				"amount":        10,
+				"currency":      "USD",
+			},
+			mockErr:        fmt.Errorf("storage: %w", storage.ErrWalletFrozen),
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet is frozen"},
+		},
+		{
+			name: "closed wallet",
+			requestBody: map[string]interface{}{
+				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType": "WITHDRAW",
+				"amount":        10,
+				"currency":      "USD",
+			},
+			mockErr:        fmt.Errorf("storage: %w", storage.ErrWalletClosed),
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet is closed"},
+		},
+		{
+			name: "client closed request",
+			requestBody: map[string]interface{}{
+				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType": "WITHDRAW",
+				"amount":        10,
+				"currency":      "USD",
+			},
+			mockErr:        fmt.Errorf("%w: %w", storage.ErrCanceled, context.Canceled),
+			expectedStatus: sender.StatusClientClosedRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "request canceled"},
+		},
+		{
+			name: "database timeout",
+			requestBody: map[string]interface{}{
+				"valletId":      "c9876543-21ab-cdef-4567-89abcdef1234",
+				"operationType": "WITHDRAW",
+				"amount":        10,
+				"currency":      "USD",
+			},
+			mockErr:        fmt.Errorf("%w: %w", storage.ErrTimeout, context.DeadlineExceeded),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "operation timed out"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -161,12 +242,12 @@ func TestWalletOperationCases(t *testing.T) {
 	
 			if tt.mockErr == nil {
 				if tt.requestBody["operationType"] == "DEPOSIT" {
-					mockOp.On("DepositWallet", testWalletUUID, amount).Return(tt.mockWallet, nil)
+					mockOp.On("DepositWallet", testWalletUUID, amount, "USD", mock.Anything).Return(postgresql.OperationResult{Wallet: tt.mockWallet}, nil)
 				} else {
-					mockOp.On("WithdrawWallet", testWalletUUID, amount).Return(tt.mockWallet, nil)
+					mockOp.On("WithdrawWallet", testWalletUUID, amount, "USD", mock.Anything).Return(postgresql.OperationResult{Wallet: tt.mockWallet}, nil)
 				}
 			} else {
-				mockOp.On("WithdrawWallet", testWalletUUID, amount).Return(postgresql.Wallet{}, tt.mockErr)
+				mockOp.On("WithdrawWallet", testWalletUUID, amount, "USD", mock.Anything).Return(postgresql.OperationResult{}, tt.mockErr)
 			}
 	
 			logger := slog.Default()
@@ -197,3 +278,256 @@ func TestWalletOperationCases(t *testing.T) {
 		})
 	}
 }
+
+// TestWalletOperationIdempotencyKey — ключ из заголовка и из тела попадает в storage
+func TestWalletOperationIdempotencyKey(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	body := map[string]interface{}{
+		"valletId":      walletID.String(),
+		"operationType": "DEPOSIT",
+		"amount":        100,
+		"currency":      "USD",
+	}
+	expectedFingerprint := fingerprint(Request{WalletID: walletID, Operation: "DEPOSIT", Amount: 100, Currency: "USD"})
+
+	tests := []struct {
+		name           string
+		headerKey      string
+		bodyKey        string
+		expectedKey    string
+		expectedStatus int
+	}{
+		{name: "header key", headerKey: "key-1", expectedKey: "key-1", expectedStatus: http.StatusOK},
+		{name: "body key", bodyKey: "key-2", expectedKey: "key-2", expectedStatus: http.StatusOK},
+		{name: "same key in header and body", headerKey: "key-3", bodyKey: "key-3", expectedKey: "key-3", expectedStatus: http.StatusOK},
+		{name: "different keys", headerKey: "key-4", bodyKey: "key-5", expectedStatus: http.StatusBadRequest},
+		{name: "reserved batch item key", headerKey: itemKey("payout", 0), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOp := new(MockOperation)
+			if tt.expectedStatus == http.StatusOK {
+				mockOp.On("DepositWallet", walletID, int64(100), "USD", mock.MatchedBy(func(meta postgresql.OperationMeta) bool {
+					return meta.IdempotencyKey == tt.expectedKey && meta.Fingerprint == expectedFingerprint
+				})).Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: walletID, Balance: 100}}, nil)
+			}
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/wallet/operation", WalletOperation(slog.Default(), mockOp))
+
+			reqBody := map[string]interface{}{}
+			for k, v := range body {
+				reqBody[k] = v
+			}
+			if tt.bodyKey != "" {
+				reqBody["idempotencyKey"] = tt.bodyKey
+			}
+			raw, _ := json.Marshal(reqBody)
+
+			req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(raw))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.headerKey != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.headerKey)
+			}
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockOp.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletOperationScope(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	tests := []struct {
+		name           string
+		scope          string
+		operation      string
+		expectedStatus int
+	}{
+		{name: "deposit-only client deposits", scope: "deposit", operation: "DEPOSIT", expectedStatus: http.StatusOK},
+		{name: "deposit-only client withdraws", scope: "deposit", operation: "WITHDRAW", expectedStatus: http.StatusForbidden},
+		{name: "read-only client deposits", scope: "read", operation: "DEPOSIT", expectedStatus: http.StatusForbidden},
+		{name: "full client withdraws", scope: "full", operation: "WITHDRAW", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOp := new(MockOperation)
+			if tt.expectedStatus == http.StatusOK {
+				method := "DepositWallet"
+				if tt.operation == "WITHDRAW" {
+					method = "WithdrawWallet"
+				}
+				mockOp.On(method, walletID, int64(100), "USD", mock.MatchedBy(func(meta postgresql.OperationMeta) bool {
+					return meta.OwnerID == "client-1"
+				})).Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: walletID, Balance: 100}}, nil)
+			}
+
+			principal, err := auth.NewPrincipal("client-1", tt.scope)
+			assert.NoError(t, err)
+
+			raw, _ := json.Marshal(map[string]interface{}{
+				"valletId":      walletID.String(),
+				"operationType": tt.operation,
+				"amount":        100,
+				"currency":      "USD",
+			})
+
+			req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(raw))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+			rec := httptest.NewRecorder()
+
+			WalletOperation(slog.Default(), mockOp).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			mockOp.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWalletOperationForeignWallet(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	mockOp := new(MockOperation)
+	mockOp.On("DepositWallet", walletID, int64(100), "USD", mock.Anything).
+		Return(postgresql.OperationResult{}, fmt.Errorf("storage: %w", storage.ErrForbidden))
+
+	raw, _ := json.Marshal(map[string]interface{}{
+		"valletId":      walletID.String(),
+		"operationType": "DEPOSIT",
+		"amount":        100,
+		"currency":      "USD",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	WalletOperation(slog.Default(), mockOp).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	var res response.Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, response.Response{Status: response.StatusError, Error: "access denied"}, res)
+}
+
+func TestWalletOperationLimitExceeded(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	mockOp := new(MockOperation)
+	mockOp.On("WithdrawWallet", walletID, int64(700), "USD", mock.Anything).
+		Return(postgresql.OperationResult{}, fmt.Errorf("storage: %w", &postgresql.LimitError{Limit: postgresql.LimitDailyWithdrawal, Max: 1000, Attempted: 1200}))
+
+	raw, _ := json.Marshal(map[string]interface{}{
+		"valletId":      walletID.String(),
+		"operationType": "WITHDRAW",
+		"amount":        700,
+		"currency":      "USD",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	WalletOperation(slog.Default(), mockOp).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var res LimitResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, "limit exceeded", res.Error)
+	assert.Equal(t, LimitDetails{Name: "daily_withdrawal", Max: 1000, Attempted: 1200}, res.Limit)
+}
+
+func TestWalletOperationFee(t *testing.T) {
+	walletID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+
+	tests := []struct {
+		name           string
+		operation      string
+		mockSetup      func(m *MockOperation)
+		expectedStatus int
+		expected       Response
+	}{
+		{
+			name:      "withdraw charges fee on top",
+			operation: "WITHDRAW",
+			mockSetup: func(m *MockOperation) {
+				m.On("WithdrawWallet", walletID, int64(1000), "USD", mock.Anything).
+					Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: walletID, Balance: 8970, Currency: "USD"}, Fee: 30}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expected: Response{
+				Response: response.OK(),
+				WalletID: walletID,
+				Balance:  8970,
+				Currency: "USD",
+				Amount:   1000,
+				Fee:      30,
+				Total:    1030,
+			},
+		},
+		{
+			name:      "deposit credits amount less fee",
+			operation: "DEPOSIT",
+			mockSetup: func(m *MockOperation) {
+				m.On("DepositWallet", walletID, int64(1000), "USD", mock.Anything).
+					Return(postgresql.OperationResult{Wallet: postgresql.Wallet{WalletID: walletID, Balance: 990, Currency: "USD"}, Fee: 10}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expected: Response{
+				Response: response.OK(),
+				WalletID: walletID,
+				Balance:  990,
+				Currency: "USD",
+				Amount:   1000,
+				Fee:      10,
+				Total:    990,
+			},
+		},
+		{
+			name:      "fee exceeds deposit",
+			operation: "DEPOSIT",
+			mockSetup: func(m *MockOperation) {
+				m.On("DepositWallet", walletID, int64(1000), "USD", mock.Anything).
+					Return(postgresql.OperationResult{}, fmt.Errorf("storage: %w", storage.ErrFeeExceedsAmount))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expected:       Response{Response: response.Error("amount does not cover fee")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOp := new(MockOperation)
+			tt.mockSetup(mockOp)
+
+			raw, _ := json.Marshal(map[string]interface{}{
+				"valletId":      walletID.String(),
+				"operationType": tt.operation,
+				"amount":        1000,
+				"currency":      "USD",
+			})
+
+			req := httptest.NewRequest("POST", "/api/v1/wallet/operation", bytes.NewBuffer(raw))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			WalletOperation(slog.Default(), mockOp).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expected, res)
+			mockOp.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"wallet/internal/http-server/handlers/transaction"
+	"wallet/internal/http-server/middleware/auth"
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/internal/rates"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+type Transferer interface {
+	GetWallet(ctx context.Context, walletID uuid.UUID) (postgresql.Wallet, error)
+	TransferWallet(ctx context.Context, req postgresql.TransferRequest, meta postgresql.OperationMeta) (postgresql.Transfer, error)
+}
+
+type Converter interface {
+	Convert(amount int64, from, to string) (rates.Conversion, error)
+}
+
+type Request struct {
+	FromWalletID   uuid.UUID `json:"fromWalletId" validate:"required"`
+	ToWalletID     uuid.UUID `json:"toWalletId" validate:"required"`
+	Amount         int64     `json:"amount" validate:"required,min=1"`
+	Currency       string    `json:"currency" validate:"required,iso4217"`
+	IdempotencyKey string    `json:"idempotencyKey,omitempty" validate:"max=255"`
+}
+
+type Wallet struct {
+	WalletID uuid.UUID `json:"walletId"`
+	Balance  int64     `json:"balance"`
+	Currency string    `json:"currency"`
+}
+
+// Response — Amount списан в Currency, CreditAmount зачислен в валюте
+// получателя. Fee — комиссия, списанная с отправителя сверх Amount, Total =
+// Amount + Fee. Rate заполнен только для переводов между валютами.
+type Response struct {
+	resp.Response
+	From         Wallet `json:"from"`
+	To           Wallet `json:"to"`
+	Amount       int64  `json:"amount"`
+	Fee          int64  `json:"fee"`
+	Total        int64  `json:"total"`
+	Currency     string `json:"currency"`
+	CreditAmount int64  `json:"creditAmount"`
+	Rate         string `json:"rate,omitempty"`
+}
+
+func Transfer(log *slog.Logger, transferer Transferer, converter Converter) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.transfer.Transfer"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		if req.FromWalletID == req.ToWalletID {
+			sender.SendError(w, r, log, http.StatusBadRequest, "source and destination wallets must differ", errors.New("same wallet transfer"))
+			return
+		}
+
+		if key := r.Header.Get(transaction.IdempotencyKeyHeader); key != "" {
+			if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+				sender.SendError(w, r, log, http.StatusBadRequest, "idempotency key mismatch", errors.New("header and body idempotency keys differ"))
+				return
+			}
+			req.IdempotencyKey = key
+		}
+		if transaction.SendReservedKeyError(w, r, log, req.IdempotencyKey) {
+			return
+		}
+
+		meta := postgresql.OperationMeta{
+			RequestID:      middleware.GetReqID(r.Context()),
+			IdempotencyKey: req.IdempotencyKey,
+			Fingerprint:    fingerprint(req),
+			OwnerID:        auth.OwnerScope(r.Context()),
+		}
+
+		// Валюта кошелька не меняется, поэтому читать ее можно вне транзакции:
+		// под блокировкой storage все равно сверяет валюты обеих сторон.
+		to, err := transferer.GetWallet(r.Context(), req.ToWalletID)
+		if err != nil {
+			if sender.SendContextError(w, r, log, err) {
+				return
+			}
+			if errors.Is(err, storage.ErrWalletNotFound) {
+				sender.SendError(w, r, log, http.StatusNotFound, "walletId not found", err)
+				return
+			}
+			sender.SendError(w, r, log, http.StatusBadRequest, "operation failed", err)
+			return
+		}
+
+		conversion, err := converter.Convert(req.Amount, req.Currency, to.Currency)
+		if err != nil {
+			switch {
+			case errors.Is(err, rates.ErrRateNotFound):
+				sender.SendError(w, r, log, http.StatusUnprocessableEntity, "exchange rate not available", err)
+			case errors.Is(err, rates.ErrAmountTooSmall):
+				sender.SendError(w, r, log, http.StatusUnprocessableEntity, "amount too small to convert", err)
+			default:
+				sender.SendError(w, r, log, http.StatusBadRequest, "operation failed", err)
+			}
+			return
+		}
+
+		res, err := transferer.TransferWallet(r.Context(), postgresql.TransferRequest{
+			FromID:         req.FromWalletID,
+			ToID:           req.ToWalletID,
+			Amount:         req.Amount,
+			Currency:       req.Currency,
+			CreditAmount:   conversion.Amount,
+			CreditCurrency: to.Currency,
+			Rate:           conversion.Rate,
+		}, meta)
+		if err != nil {
+			transaction.SendOperationError(w, r, log, err)
+			return
+		}
+
+		log.Info("transfer completed",
+			slog.String("from", req.FromWalletID.String()),
+			slog.String("to", req.ToWalletID.String()),
+		)
+
+		render.JSON(w, r, Response{
+			Response:     resp.OK(),
+			From:         Wallet{WalletID: res.From.WalletID, Balance: res.From.Balance, Currency: res.From.Currency},
+			To:           Wallet{WalletID: res.To.WalletID, Balance: res.To.Balance, Currency: res.To.Currency},
+			Amount:       res.Amount,
+			Fee:          res.Fee,
+			Total:        res.Amount + res.Fee,
+			Currency:     res.Currency,
+			CreditAmount: res.CreditAmount,
+			Rate:         res.Rate,
+		})
+	}
+}
+
+func fingerprint(req Request) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("TRANSFER|%s|%s|%d|%s", req.FromWalletID, req.ToWalletID, req.Amount, req.Currency)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,227 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet/internal/lib/api/response"
+	"wallet/internal/rates"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTransferer struct {
+	mock.Mock
+}
+
+func (m *MockTransferer) GetWallet(ctx context.Context, walletID uuid.UUID) (postgresql.Wallet, error) {
+	args := m.Called(walletID)
+	return args.Get(0).(postgresql.Wallet), args.Error(1)
+}
+
+func (m *MockTransferer) TransferWallet(ctx context.Context, req postgresql.TransferRequest, meta postgresql.OperationMeta) (postgresql.Transfer, error) {
+	args := m.Called(req, meta)
+	return args.Get(0).(postgresql.Transfer), args.Error(1)
+}
+
+func newConverter(t *testing.T) *rates.Converter {
+	provider, err := rates.NewStatic(rates.Quote{From: "USD", To: "EUR", Rate: "0.92"})
+	if err != nil {
+		t.Fatalf("Failed to create rate provider: %v", err)
+	}
+	return rates.NewConverter(provider, rates.RoundHalfEven)
+}
+
+func TestTransfer(t *testing.T) {
+	fromID := uuid.MustParse("f22bd5ed-9155-4ba0-90c4-4880912d7ad4")
+	toID := uuid.MustParse("a45c73fd-3e36-466a-8e57-15e1cf0f35d2")
+
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		toCurrency     string
+		mockRequest    postgresql.TransferRequest
+		mockTransfer   postgresql.Transfer
+		mockErr        error
+		skipMock       bool
+		expectedStatus int
+		expectedResp   response.Response
+	}{
+		{
+			name: "successful transfer",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       40,
+				"currency":     "USD",
+			},
+			toCurrency:  "USD",
+			mockRequest: postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 40, Currency: "USD", CreditAmount: 40, CreditCurrency: "USD"},
+			mockTransfer: postgresql.Transfer{
+				From:   postgresql.Wallet{WalletID: fromID, Balance: 59, Currency: "USD"},
+				To:     postgresql.Wallet{WalletID: toID, Balance: 140, Currency: "USD"},
+				Amount: 40,
+				Fee:    1,
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp:   response.Response{Status: response.StatusOK},
+		},
+		{
+			name: "insufficient funds",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       400,
+				"currency":     "USD",
+			},
+			toCurrency:     "USD",
+			mockRequest:    postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 400, Currency: "USD", CreditAmount: 400, CreditCurrency: "USD"},
+			mockErr:        storage.ErrInsufficientFunds,
+			expectedStatus: http.StatusNotFound,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "insufficient funds"},
+		},
+		{
+			name: "wallet not found",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       400,
+				"currency":     "USD",
+			},
+			toCurrency:     "USD",
+			mockRequest:    postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 400, Currency: "USD", CreditAmount: 400, CreditCurrency: "USD"},
+			mockErr:        storage.ErrWalletNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "walletId not found"},
+		},
+		{
+			name: "fee exceeds amount",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       1,
+				"currency":     "USD",
+			},
+			toCurrency:     "USD",
+			mockRequest:    postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 1, Currency: "USD", CreditAmount: 1, CreditCurrency: "USD"},
+			mockErr:        storage.ErrFeeExceedsAmount,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "amount does not cover fee"},
+		},
+		{
+			name: "frozen wallet",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       40,
+				"currency":     "USD",
+			},
+			toCurrency:     "USD",
+			mockRequest:    postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 40, Currency: "USD", CreditAmount: 40, CreditCurrency: "USD"},
+			mockErr:        storage.ErrWalletFrozen,
+			expectedStatus: http.StatusConflict,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "wallet is frozen"},
+		},
+		{
+			name: "cross currency transfer",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       1000,
+				"currency":     "USD",
+			},
+			toCurrency:  "EUR",
+			mockRequest: postgresql.TransferRequest{FromID: fromID, ToID: toID, Amount: 1000, Currency: "USD", CreditAmount: 920, CreditCurrency: "EUR", Rate: "0.92"},
+			mockTransfer: postgresql.Transfer{
+				From:           postgresql.Wallet{WalletID: fromID, Balance: 0, Currency: "USD"},
+				To:             postgresql.Wallet{WalletID: toID, Balance: 920, Currency: "EUR"},
+				Amount:         1000,
+				Currency:       "USD",
+				CreditAmount:   920,
+				CreditCurrency: "EUR",
+				Rate:           "0.92",
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp:   response.Response{Status: response.StatusOK},
+		},
+		{
+			name: "missing exchange rate",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   toID.String(),
+				"amount":       1000,
+				"currency":     "USD",
+			},
+			toCurrency:     "JPY",
+			skipMock:       true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "exchange rate not available"},
+		},
+		{
+			name: "same wallet",
+			requestBody: map[string]interface{}{
+				"fromWalletId": fromID.String(),
+				"toWalletId":   fromID.String(),
+				"amount":       10,
+				"currency":     "USD",
+			},
+			skipMock:       true,
+			expectedStatus: http.StatusBadRequest,
+			expectedResp:   response.Response{Status: response.StatusError, Error: "source and destination wallets must differ"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTransferer := new(MockTransferer)
+			if tt.toCurrency != "" {
+				mockTransferer.On("GetWallet", toID).Return(postgresql.Wallet{WalletID: toID, Currency: tt.toCurrency}, nil)
+			}
+			if !tt.skipMock {
+				mockTransferer.On("TransferWallet", tt.mockRequest, mock.Anything).Return(tt.mockTransfer, tt.mockErr)
+			}
+
+			r := chi.NewRouter()
+			r.Post("/api/v1/transfers", Transfer(slog.Default(), mockTransferer, newConverter(t)))
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/api/v1/transfers", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			fmt.Printf("Sending request: %s\n", req.URL)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tt.expectedResp, res.Response)
+			if tt.mockErr == nil && !tt.skipMock {
+				assert.Equal(t, tt.mockTransfer.From.Balance, res.From.Balance)
+				assert.Equal(t, tt.mockTransfer.To.Balance, res.To.Balance)
+				assert.Equal(t, tt.mockTransfer.CreditAmount, res.CreditAmount)
+				assert.Equal(t, tt.mockTransfer.Rate, res.Rate)
+				assert.Equal(t, tt.mockTransfer.Fee, res.Fee)
+				assert.Equal(t, tt.mockTransfer.Amount+tt.mockTransfer.Fee, res.Total)
+			}
+
+			mockTransferer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+var deliveryStatuses = map[string]bool{
+	postgresql.DeliveryPending:   true,
+	postgresql.DeliveryDelivered: true,
+	postgresql.DeliveryDead:      true,
+}
+
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, webhook postgresql.Webhook) (postgresql.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]postgresql.Webhook, error)
+	DeactivateWebhook(ctx context.Context, webhookID uuid.UUID) error
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]postgresql.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (postgresql.WebhookDelivery, error)
+}
+
+// Request — без secret сервис генерирует его сам; без owner_id вебхук
+// получает события всех кошельков.
+type Request struct {
+	URL     string `json:"url" validate:"required,url,max=2048"`
+	Secret  string `json:"secret,omitempty" validate:"omitempty,min=16,max=255"`
+	OwnerID string `json:"owner_id,omitempty" validate:"max=255"`
+}
+
+// Webhook — секрет отдается только при создании.
+type Webhook struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Delivery struct {
+	DeliveryID     uuid.UUID  `json:"delivery_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	Status         string     `json:"delivery_status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Webhook Webhook `json:"webhook"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type DeliveriesResponse struct {
+	resp.Response
+	WebhookID  uuid.UUID  `json:"webhook_id"`
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+type DeliveryResponse struct {
+	resp.Response
+	WebhookID uuid.UUID `json:"webhook_id"`
+	Delivery  Delivery  `json:"delivery"`
+}
+
+func Create(log *slog.Logger, store WebhookStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.webhooks.Create"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "failed to decode request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			validatorErr := err.(validator.ValidationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validatorErr))
+			return
+		}
+
+		if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+			err := errors.New("url must use http or https")
+			sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		if req.Secret == "" {
+			secret, err := generateSecret()
+			if err != nil {
+				sender.SendError(w, r, log, http.StatusInternalServerError, "failed to generate secret", err)
+				return
+			}
+			req.Secret = secret
+		}
+
+		webhook, err := store.CreateWebhook(r.Context(), postgresql.Webhook{
+			URL:     req.URL,
+			Secret:  req.Secret,
+			OwnerID: req.OwnerID,
+		})
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("webhook created", slog.String("webhook_id", webhook.WebhookID.String()))
+
+		res := newWebhook(webhook)
+		res.Secret = webhook.Secret
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{Response: resp.OK(), Webhook: res})
+	}
+}
+
+func List(log *slog.Logger, store WebhookStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.webhooks.List"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhooks, err := store.ListWebhooks(r.Context())
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		result := make([]Webhook, 0, len(webhooks))
+		for _, webhook := range webhooks {
+			result = append(result, newWebhook(webhook))
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Webhooks: result})
+	}
+}
+
+// Deactivate отключает вебхук. Ожидающие доставки больше не отправляются,
+// журнал доставок сохраняется.
+func Deactivate(log *slog.Logger, store WebhookStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.webhooks.Deactivate"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhookID, err := uuid.Parse(chi.URLParam(r, "WEBHOOK_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		if err := store.DeactivateWebhook(r.Context(), webhookID); err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("webhook deactivated", slog.String("webhook_id", webhookID.String()))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// FetchDeliveries отдает последние доставки вебхука. Параметры: status
+// (PENDING, DELIVERED, DEAD) и limit.
+func FetchDeliveries(log *slog.Logger, store WebhookStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.webhooks.FetchDeliveries"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhookID, err := uuid.Parse(chi.URLParam(r, "WEBHOOK_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		query := r.URL.Query()
+
+		status := strings.ToUpper(query.Get("status"))
+		if status != "" && !deliveryStatuses[status] {
+			err := fmt.Errorf("unsupported delivery status %q", status)
+			sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		limit := defaultLimit
+		if v := query.Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > maxLimit {
+				err := fmt.Errorf("limit must be between 1 and %d", maxLimit)
+				sender.SendError(w, r, log, http.StatusBadRequest, err.Error(), err)
+				return
+			}
+		}
+
+		deliveries, err := store.ListDeliveries(r.Context(), webhookID, status, limit)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		result := make([]Delivery, 0, len(deliveries))
+		for _, d := range deliveries {
+			result = append(result, newDelivery(d))
+		}
+
+		render.JSON(w, r, DeliveriesResponse{
+			Response:   resp.OK(),
+			WebhookID:  webhookID,
+			Deliveries: result,
+		})
+	}
+}
+
+// RetryDelivery возвращает доставку из DEAD в очередь.
+func RetryDelivery(log *slog.Logger, store WebhookStore) http.HandlerFunc {
+	if log == nil {
+		log = slog.Default()
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "handlers.webhooks.RetryDelivery"
+
+		log := log.With(
+			slog.String("fn", fn),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		webhookID, err := uuid.Parse(chi.URLParam(r, "WEBHOOK_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		deliveryID, err := uuid.Parse(chi.URLParam(r, "DELIVERY_UUID"))
+		if err != nil {
+			sender.SendError(w, r, log, http.StatusBadRequest, "invalid UUID", err)
+			return
+		}
+
+		delivery, err := store.RetryDelivery(r.Context(), webhookID, deliveryID)
+		if err != nil {
+			sendError(w, r, log, err)
+			return
+		}
+
+		log.Info("webhook delivery requeued",
+			slog.String("webhook_id", webhookID.String()),
+			slog.String("delivery_id", deliveryID.String()),
+		)
+
+		render.JSON(w, r, DeliveryResponse{
+			Response:  resp.OK(),
+			WebhookID: webhookID,
+			Delivery:  newDelivery(delivery),
+		})
+	}
+}
+
+func newWebhook(webhook postgresql.Webhook) Webhook {
+	return Webhook{
+		WebhookID: webhook.WebhookID,
+		URL:       webhook.URL,
+		OwnerID:   webhook.OwnerID,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func newDelivery(d postgresql.WebhookDelivery) Delivery {
+	return Delivery{
+		DeliveryID:     d.DeliveryID,
+		EventID:        d.EventID,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func sendError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if sender.SendContextError(w, r, log, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, storage.ErrWebhookNotFound):
+		sender.SendError(w, r, log, http.StatusNotFound, "webhook not found", err)
+	case errors.Is(err, storage.ErrDeliveryNotFound):
+		sender.SendError(w, r, log, http.StatusNotFound, "dead delivery not found", err)
+	default:
+		sender.SendError(w, r, log, http.StatusInternalServerError, "failed to process webhook", err)
+	}
+}
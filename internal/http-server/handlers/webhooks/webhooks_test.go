@@ -0,0 +1,273 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookStore struct {
+	mock.Mock
+}
+
+func (m *MockWebhookStore) CreateWebhook(ctx context.Context, webhook postgresql.Webhook) (postgresql.Webhook, error) {
+	args := m.Called(webhook)
+	return args.Get(0).(postgresql.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookStore) ListWebhooks(ctx context.Context) ([]postgresql.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]postgresql.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookStore) DeactivateWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	args := m.Called(webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookStore) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]postgresql.WebhookDelivery, error) {
+	args := m.Called(webhookID, status, limit)
+	return args.Get(0).([]postgresql.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookStore) RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (postgresql.WebhookDelivery, error) {
+	args := m.Called(webhookID, deliveryID)
+	return args.Get(0).(postgresql.WebhookDelivery), args.Error(1)
+}
+
+var (
+	webhookID  = uuid.MustParse("8a3c5bde-02a4-4d8e-9f0e-6c1b0d7e2f11")
+	deliveryID = uuid.MustParse("c4f0d9a2-5b7e-4e1a-8d3c-2f6a9b1e0d44")
+)
+
+func newRouter(store WebhookStore) *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/api/v1/admin/webhooks", Create(nil, store))
+	r.Get("/api/v1/admin/webhooks", List(nil, store))
+	r.Delete("/api/v1/admin/webhooks/{WEBHOOK_UUID}", Deactivate(nil, store))
+	r.Get("/api/v1/admin/webhooks/{WEBHOOK_UUID}/deliveries", FetchDeliveries(nil, store))
+	r.Post("/api/v1/admin/webhooks/{WEBHOOK_UUID}/deliveries/{DELIVERY_UUID}/retry", RetryDelivery(nil, store))
+	return r
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *MockWebhookStore)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "with secret",
+			body: `{"url": "https://example.com/hooks", "secret": "0123456789abcdef", "owner_id": "partner"}`,
+			mockSetup: func(m *MockWebhookStore) {
+				m.On("CreateWebhook", postgresql.Webhook{URL: "https://example.com/hooks", Secret: "0123456789abcdef", OwnerID: "partner"}).
+					Return(postgresql.Webhook{WebhookID: webhookID, URL: "https://example.com/hooks", Secret: "0123456789abcdef", OwnerID: "partner", Active: true}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "generated secret",
+			body: `{"url": "https://example.com/hooks"}`,
+			mockSetup: func(m *MockWebhookStore) {
+				m.On("CreateWebhook", mock.MatchedBy(func(w postgresql.Webhook) bool {
+					return len(w.Secret) == 64
+				})).Return(postgresql.Webhook{WebhookID: webhookID, URL: "https://example.com/hooks", Secret: "generated", Active: true}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing url",
+			body:           `{}`,
+			mockSetup:      func(m *MockWebhookStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field URL is a required field",
+		},
+		{
+			name:           "unsupported scheme",
+			body:           `{"url": "ftp://example.com/hooks"}`,
+			mockSetup:      func(m *MockWebhookStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "url must use http or https",
+		},
+		{
+			name:           "short secret",
+			body:           `{"url": "https://example.com/hooks", "secret": "short"}`,
+			mockSetup:      func(m *MockWebhookStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "field Secret is not valid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockWebhookStore)
+			tt.mockSetup(store)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			newRouter(store).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+				assert.Equal(t, webhookID, res.Webhook.WebhookID)
+				assert.NotEmpty(t, res.Webhook.Secret)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListHidesSecret(t *testing.T) {
+	store := new(MockWebhookStore)
+	store.On("ListWebhooks").Return([]postgresql.Webhook{
+		{WebhookID: webhookID, URL: "https://example.com/hooks", Secret: "0123456789abcdef", Active: true},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks", nil)
+	rec := httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "0123456789abcdef")
+
+	var res ListResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Len(t, res.Webhooks, 1)
+}
+
+func TestDeactivate(t *testing.T) {
+	store := new(MockWebhookStore)
+	store.On("DeactivateWebhook", webhookID).Return(nil)
+	store.On("DeactivateWebhook", mock.Anything).Return(storage.ErrWebhookNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/webhooks/"+webhookID.String(), nil)
+	rec := httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/webhooks/"+uuid.NewString(), nil)
+	rec = httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFetchDeliveries(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(m *MockWebhookStore)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "dead letters",
+			query: "?status=dead&limit=10",
+			mockSetup: func(m *MockWebhookStore) {
+				m.On("ListDeliveries", webhookID, postgresql.DeliveryDead, 10).Return([]postgresql.WebhookDelivery{
+					{DeliveryID: deliveryID, WebhookID: webhookID, Status: postgresql.DeliveryDead, Attempts: 10, LastStatusCode: 500, NextAttemptAt: time.Now()},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "defaults",
+			query: "",
+			mockSetup: func(m *MockWebhookStore) {
+				m.On("ListDeliveries", webhookID, "", defaultLimit).Return([]postgresql.WebhookDelivery{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown status",
+			query:          "?status=LOST",
+			mockSetup:      func(m *MockWebhookStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `unsupported delivery status "LOST"`,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=1000",
+			mockSetup:      func(m *MockWebhookStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "limit must be between 1 and 500",
+		},
+		{
+			name:  "webhook not found",
+			query: "",
+			mockSetup: func(m *MockWebhookStore) {
+				m.On("ListDeliveries", webhookID, "", defaultLimit).Return([]postgresql.WebhookDelivery(nil), storage.ErrWebhookNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "webhook not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockWebhookStore)
+			tt.mockSetup(store)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/"+webhookID.String()+"/deliveries"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			newRouter(store).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var res response.Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tt.expectedError, res.Error)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryDelivery(t *testing.T) {
+	store := new(MockWebhookStore)
+	store.On("RetryDelivery", webhookID, deliveryID).
+		Return(postgresql.WebhookDelivery{DeliveryID: deliveryID, WebhookID: webhookID, Status: postgresql.DeliveryPending}, nil)
+	store.On("RetryDelivery", webhookID, mock.Anything).
+		Return(postgresql.WebhookDelivery{}, storage.ErrDeliveryNotFound)
+
+	url := "/api/v1/admin/webhooks/" + webhookID.String() + "/deliveries/"
+
+	req := httptest.NewRequest(http.MethodPost, url+deliveryID.String()+"/retry", nil)
+	rec := httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res DeliveryResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, postgresql.DeliveryPending, res.Delivery.Status)
+
+	req = httptest.NewRequest(http.MethodPost, url+uuid.NewString()+"/retry", nil)
+	rec = httptest.NewRecorder()
+	newRouter(store).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "wallet/internal/lib/api/response"
+	"wallet/internal/lib/api/sender"
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type Permission string
+
+const (
+	PermRead     Permission = "read"
+	PermCreate   Permission = "create"
+	PermDeposit  Permission = "deposit"
+	PermWithdraw Permission = "withdraw"
+	PermTransfer Permission = "transfer"
+	PermAdmin    Permission = "admin"
+)
+
+// scopes — набор прав для каждого значения api_clients.scope.
+var scopes = map[string][]Permission{
+	"read":    {PermRead},
+	"deposit": {PermRead, PermDeposit},
+	"full":    {PermRead, PermCreate, PermDeposit, PermWithdraw, PermTransfer},
+	"admin":   {PermRead, PermCreate, PermDeposit, PermWithdraw, PermTransfer, PermAdmin},
+}
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("access denied")
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+// Principal — аутентифицированный клиент. ClientID совпадает с owner_id его
+// кошельков.
+type Principal struct {
+	ClientID    string
+	Permissions []Permission
+}
+
+func NewPrincipal(clientID, scope string) (Principal, error) {
+	perms, ok := scopes[scope]
+	if !ok {
+		return Principal{}, ErrUnknownScope
+	}
+	return Principal{ClientID: clientID, Permissions: perms}, nil
+}
+
+func IsValidScope(scope string) bool {
+	_, ok := scopes[scope]
+	return ok
+}
+
+func (p Principal) Can(perm Permission) bool {
+	for _, have := range p.Permissions {
+		if have == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// Check проверяет право принципала из контекста. Запрос без принципала
+// пропускается: значит, аутентификация выключена (AUTH_MODE=none).
+func Check(ctx context.Context, perm Permission) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if !p.Can(perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// OwnerScope возвращает владельца, кошельками которого ограничен запрос.
+// Пустая строка — без ограничений: аутентификация выключена или запрос от
+// администратора.
+func OwnerScope(ctx context.Context) string {
+	p, ok := FromContext(ctx)
+	if !ok || p.Can(PermAdmin) {
+		return ""
+	}
+	return p.ClientID
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type ClientProvider interface {
+	ClientByKeyHash(ctx context.Context, keyHash string) (postgresql.APIClient, error)
+}
+
+// New проверяет ключ из заголовка X-API-Key и кладет Principal в контекст.
+func New(log *slog.Logger, provider ClientProvider) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		log.Info("auth middleware enabled")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(slog.String("request_id", middleware.GetReqID(r.Context())))
+
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				Unauthorized(w, r, log, ErrUnauthorized)
+				return
+			}
+
+			client, err := provider.ClientByKeyHash(r.Context(), HashKey(key))
+			if err != nil {
+				if errors.Is(err, storage.ErrClientNotFound) {
+					Unauthorized(w, r, log, err)
+					return
+				}
+				if sender.SendContextError(w, r, log, err) {
+					return
+				}
+				log.Error("failed to load api client", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+
+			principal, err := NewPrincipal(client.ClientID, client.Scope)
+			if err != nil {
+				log.Error("api client has unknown scope", slog.String("client_id", client.ClientID), sl.Err(err))
+				Forbidden(w, r, log, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Require пропускает запрос, только если у принципала есть право perm.
+func Require(perm Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if err := Check(r.Context(), perm); err != nil {
+				Forbidden(w, r, slog.Default(), err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func Unauthorized(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	log.Warn("unauthorized", sl.Err(err))
+	w.WriteHeader(http.StatusUnauthorized)
+	render.JSON(w, r, resp.Error("unauthorized"))
+}
+
+func Forbidden(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	log.Warn("access denied", sl.Err(err))
+	w.WriteHeader(http.StatusForbidden)
+	render.JSON(w, r, resp.Error("access denied"))
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet/internal/lib/api/response"
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClientProvider struct {
+	mock.Mock
+}
+
+func (m *MockClientProvider) ClientByKeyHash(ctx context.Context, keyHash string) (postgresql.APIClient, error) {
+	args := m.Called(keyHash)
+	return args.Get(0).(postgresql.APIClient), args.Error(1)
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		setup          func(m *MockClientProvider)
+		perm           Permission
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "missing key",
+			setup:          func(m *MockClientProvider) {},
+			perm:           PermRead,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "unauthorized",
+		},
+		{
+			name: "unknown key",
+			key:  "bad",
+			setup: func(m *MockClientProvider) {
+				m.On("ClientByKeyHash", HashKey("bad")).Return(postgresql.APIClient{}, storage.ErrClientNotFound)
+			},
+			perm:           PermRead,
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "unauthorized",
+		},
+		{
+			name: "read-only client reads",
+			key:  "secret",
+			setup: func(m *MockClientProvider) {
+				m.On("ClientByKeyHash", HashKey("secret")).Return(postgresql.APIClient{ClientID: "client-1", Scope: "read"}, nil)
+			},
+			perm:           PermRead,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "read-only client transfers",
+			key:  "secret",
+			setup: func(m *MockClientProvider) {
+				m.On("ClientByKeyHash", HashKey("secret")).Return(postgresql.APIClient{ClientID: "client-1", Scope: "read"}, nil)
+			},
+			perm:           PermTransfer,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := new(MockClientProvider)
+			tt.setup(provider)
+
+			var owner string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				owner = OwnerScope(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := New(discardLogger(), provider)(Require(tt.perm)(next))
+
+			req := httptest.NewRequest("GET", "/api/v1/wallets", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedError != "" {
+				var res response.Response
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				assert.Equal(t, response.Response{Status: response.StatusError, Error: tt.expectedError}, res)
+			} else {
+				assert.Equal(t, "client-1", owner)
+			}
+
+			provider.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOwnerScope(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", OwnerScope(req.Context()))
+	assert.NoError(t, Check(req.Context(), PermAdmin))
+
+	admin, err := NewPrincipal("ops", "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "", OwnerScope(WithPrincipal(req.Context(), admin)))
+
+	_, err = NewPrincipal("client-1", "everything")
+	assert.ErrorIs(t, err, ErrUnknownScope)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
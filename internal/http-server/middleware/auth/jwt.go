@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type JWTConfig struct {
+	Audience     string
+	Issuer       string
+	OwnerClaim   string
+	ScopeClaim   string
+	DefaultScope string
+}
+
+// Verifier проверяет подпись и стандартные поля токена и превращает его
+// в Principal. Владелец кошельков берется из OwnerClaim, права — из ScopeClaim
+// (если его нет, из DefaultScope).
+type Verifier struct {
+	keys   *KeySet
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewVerifier(keys *KeySet, cfg JWTConfig) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256", "HS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	return &Verifier{keys: keys, cfg: cfg, parser: jwt.NewParser(opts...)}
+}
+
+func (v *Verifier) Verify(raw string) (Principal, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(raw, claims, v.keyFunc)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	owner, _ := claims[v.cfg.OwnerClaim].(string)
+	if owner == "" {
+		return Principal{}, fmt.Errorf("%w: claim %q is missing", ErrUnauthorized, v.cfg.OwnerClaim)
+	}
+
+	scope := v.cfg.DefaultScope
+	if s, ok := claims[v.cfg.ScopeClaim].(string); ok && s != "" {
+		scope = s
+	}
+
+	return NewPrincipal(owner, scope)
+}
+
+// keyFunc выбирает ключ по kid и проверяет, что его тип подходит к alg:
+// иначе открытый RSA-ключ можно было бы подсунуть как HMAC-секрет.
+func (v *Verifier) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := v.keys.Key(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	switch token.Method.Alg() {
+	case "RS256":
+		_, ok = key.(*rsa.PublicKey)
+	case "ES256":
+		_, ok = key.(*ecdsa.PublicKey)
+	case "HS256":
+		_, ok = key.([]byte)
+	}
+	if !ok {
+		return nil, fmt.Errorf("key %q does not match alg %s", kid, token.Method.Alg())
+	}
+
+	return key, nil
+}
+
+// NewJWT проверяет токен из заголовка Authorization: Bearer и кладет
+// Principal в контекст.
+func NewJWT(log *slog.Logger, verifier *Verifier) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		log.Info("jwt auth middleware enabled")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(slog.String("request_id", middleware.GetReqID(r.Context())))
+
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || raw == "" {
+				Unauthorized(w, r, log, ErrUnauthorized)
+				return
+			}
+
+			principal, err := verifier.Verify(raw)
+			if err != nil {
+				if errors.Is(err, ErrUnknownScope) {
+					Forbidden(w, r, log, err)
+					return
+				}
+				Unauthorized(w, r, log, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
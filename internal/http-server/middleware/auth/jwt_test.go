@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid,
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path,
+		rsaJWK("rsa-1", rsaKey),
+		ecJWK("ec-1", ecKey),
+		map[string]string{"kty": "oct", "kid": "hs-1", "k": b64(hmacSecret)},
+	)
+
+	keys, err := LoadKeySet(path)
+	require.NoError(t, err)
+
+	verifier := NewVerifier(keys, JWTConfig{
+		Audience:     "wallet",
+		OwnerClaim:   "sub",
+		ScopeClaim:   "scope",
+		DefaultScope: "read",
+	})
+
+	now := time.Now()
+	valid := func(extra jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{"sub": "client-1", "aud": "wallet", "exp": now.Add(time.Minute).Unix()}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedErr   bool
+		expectedScope Permission
+	}{
+		{name: "RS256", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid(nil)), expectedScope: PermRead},
+		{name: "ES256", token: sign(t, jwt.SigningMethodES256, "ec-1", ecKey, valid(jwt.MapClaims{"scope": "full"})), expectedScope: PermWithdraw},
+		{name: "HS256", token: sign(t, jwt.SigningMethodHS256, "hs-1", hmacSecret, valid(nil)), expectedScope: PermRead},
+		{name: "expired", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})), expectedErr: true},
+		{name: "without exp", token: sign(t, jwt.SigningMethodHS256, "hs-1", hmacSecret, jwt.MapClaims{"sub": "client-1", "aud": "wallet"}), expectedErr: true},
+		{name: "not yet valid", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()})), expectedErr: true},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid(jwt.MapClaims{"aud": "billing"})), expectedErr: true},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, valid(nil)), expectedErr: true},
+		{name: "hmac signed with rsa key id", token: sign(t, jwt.SigningMethodHS256, "rsa-1", hmacSecret, valid(nil)), expectedErr: true},
+		{name: "missing owner", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, valid(jwt.MapClaims{"sub": ""})), expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tt.token)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "client-1", principal.ClientID)
+			assert.True(t, principal.Can(tt.expectedScope))
+		})
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", first))
+
+	keys, err := LoadKeySet(path)
+	require.NoError(t, err)
+
+	reloaded, err := keys.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeJWKS(t, path, rsaJWK("rsa-2", second))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	reloaded, err = keys.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	_, err = keys.Key("rsa-1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = keys.Key("rsa-2")
+	assert.NoError(t, err)
+
+	// Битый файл не сбрасывает уже загруженные ключи.
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+
+	_, err = keys.Reload()
+	assert.Error(t, err)
+	_, err = keys.Key("rsa-2")
+	assert.NoError(t, err)
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, map[string]string{"kty": "oct", "kid": "hs-1", "k": b64(hmacSecret)})
+
+	keys, err := LoadKeySet(path)
+	require.NoError(t, err)
+
+	handler := NewJWT(discardLogger(), NewVerifier(keys, JWTConfig{OwnerClaim: "sub", ScopeClaim: "scope", DefaultScope: "read"}))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "client-1", OwnerScope(r.Context()))
+		}),
+	)
+
+	token := sign(t, jwt.SigningMethodHS256, "hs-1", hmacSecret, jwt.MapClaims{"sub": "client-1", "exp": time.Now().Add(time.Minute).Unix()})
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/wallets", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
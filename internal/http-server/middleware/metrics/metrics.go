@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"wallet/internal/metrics"
+)
+
+// unmatchedRoute — запросы, не попавшие ни в один маршрут. Сам путь в метку
+// не пишем, иначе число рядов не ограничено.
+const unmatchedRoute = "unmatched"
+
+func New(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			t1 := time.Now()
+			next.ServeHTTP(ww, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			labels := []string{r.Method, route, strconv.Itoa(status)}
+
+			m.HTTPRequests.WithLabelValues(labels...).Inc()
+			m.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(t1).Seconds())
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(New())
+	r.Get("/api/v1/wallets/{WALLET_UUID}", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := otelhttp.NewHandler(r, "http.server", otelhttp.WithTracerProvider(provider))
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/f22bd5ed-9155-4ba0-90c4-4880912d7ad4", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/v1/wallets/{WALLET_UUID}", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("request_id", "req-1"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/api/v1/wallets/{WALLET_UUID}"))
+}
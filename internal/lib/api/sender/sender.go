@@ -1,16 +1,37 @@
 package sender
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"wallet/internal/lib/logger/sl"
+	"wallet/storage"
 
 	"github.com/go-chi/render"
     resp "wallet/internal/lib/api/response"
 )
 
+// StatusClientClosedRequest — нестандартный код (nginx): клиент закрыл
+// соединение, не дождавшись ответа.
+const StatusClientClosedRequest = 499
+
 func SendError(w http.ResponseWriter, r *http.Request, log *slog.Logger, statusCode int, message string, err error) {
 	log.Error(message, sl.Err(err))
 	w.WriteHeader(statusCode)
 	render.JSON(w, r, resp.Error(message))
 }
+
+// SendContextError отвечает 499, если запрос отменил клиент, и 503, если
+// истек дедлайн запроса к БД. Для прочих ошибок ничего не пишет и
+// возвращает false.
+func SendContextError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) bool {
+	switch {
+	case errors.Is(err, storage.ErrCanceled):
+		SendError(w, r, log, StatusClientClosedRequest, "request canceled", err)
+	case errors.Is(err, storage.ErrTimeout):
+		SendError(w, r, log, http.StatusServiceUnavailable, "operation timed out", err)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package currency
+
+import (
+	"strconv"
+	"strings"
+)
+
+// exponents — число знаков после запятой (minor units) по ISO 4217.
+var exponents = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "BYN": 2, "CAD": 2,
+	"CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2,
+	"GEL": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KGS": 2, "KRW": 0, "KWD": 3, "KZT": 2, "MXN": 2,
+	"NOK": 2, "NZD": 2, "OMR": 3, "PLN": 2, "RON": 2, "RSD": 2, "RUB": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "UAH": 2, "USD": 2,
+	"UZS": 2, "VND": 0, "ZAR": 2,
+}
+
+func Exponent(code string) (int, bool) {
+	exp, ok := exponents[code]
+	return exp, ok
+}
+
+func IsSupported(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
+
+// Format переводит сумму в минимальных единицах в десятичную строку:
+// Format(-1234, "USD") == "-12.34", Format(5, "BHD") == "0.005".
+func Format(amount int64, code string) string {
+	exp := exponents[code]
+
+	neg := amount < 0
+	digits := strconv.FormatUint(absUint(amount), 10)
+
+	if exp > 0 {
+		if len(digits) <= exp {
+			digits = strings.Repeat("0", exp-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+	}
+
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
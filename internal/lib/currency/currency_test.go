@@ -0,0 +1,41 @@
+package currency
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		code     string
+		expected string
+	}{
+		{amount: 1234, code: "USD", expected: "12.34"},
+		{amount: -1234, code: "USD", expected: "-12.34"},
+		{amount: 5, code: "EUR", expected: "0.05"},
+		{amount: -5, code: "EUR", expected: "-0.05"},
+		{amount: 0, code: "RUB", expected: "0.00"},
+		{amount: 1500, code: "JPY", expected: "1500"},
+		{amount: 5, code: "BHD", expected: "0.005"},
+		{amount: 12345, code: "KWD", expected: "12.345"},
+		{amount: math.MinInt64, code: "JPY", expected: "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+"_"+tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Format(tt.amount, tt.code))
+		})
+	}
+}
+
+func TestExponent(t *testing.T) {
+	exp, ok := Exponent("JPY")
+	assert.True(t, ok)
+	assert.Equal(t, 0, exp)
+
+	_, ok = Exponent("XXX")
+	assert.False(t, ok)
+}
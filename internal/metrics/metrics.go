@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"wallet/storage"
+	"wallet/storage/postgresql"
+)
+
+const namespace = "wallet"
+
+const (
+	OutcomeOK                = "ok"
+	OutcomeNotFound          = "not_found"
+	OutcomeInsufficientFunds = "insufficient_funds"
+	OutcomeReplay            = "replay"
+	OutcomeError             = "error"
+)
+
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequests *prometheus.CounterVec
+	HTTPDuration *prometheus.HistogramVec
+	Operations   *prometheus.CounterVec
+	Amount       *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by chi route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by chi route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		Operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Wallet operations by type and outcome.",
+		}, []string{"operation", "outcome"}),
+		Amount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_amount_total",
+			Help:      "Amount moved by successful operations, in minor currency units.",
+		}, []string{"operation", "currency"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPDuration,
+		m.Operations,
+		m.Amount,
+	)
+
+	return m
+}
+
+// ObserveOperation учитывает результат операции; сумма считается только
+// для успешных. Повтор по ключу идемпотентности деньги не двигает, поэтому
+// считается отдельным исходом и без суммы.
+func (m *Metrics) ObserveOperation(operation string, amount int64, currency string, replayed bool, err error) {
+	if err == nil && replayed {
+		m.Operations.WithLabelValues(operation, OutcomeReplay).Inc()
+		return
+	}
+
+	m.Operations.WithLabelValues(operation, Outcome(err)).Inc()
+	if err == nil {
+		m.Amount.WithLabelValues(operation, currency).Add(float64(amount))
+	}
+}
+
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, storage.ErrWalletNotFound):
+		return OutcomeNotFound
+	case errors.Is(err, storage.ErrInsufficientFunds):
+		return OutcomeInsufficientFunds
+	default:
+		return OutcomeError
+	}
+}
+
+// RegisterDBStats экспортирует статистику пула соединений.
+func (m *Metrics) RegisterDBStats(stats func() sql.DBStats) {
+	gauge := func(name, help string, value func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stats()) })
+	}
+	counter := func(name, help string, value func(s sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stats()) })
+	}
+
+	m.Registry.MustRegister(
+		gauge("max_open_connections", "Maximum number of open connections.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("open_connections", "Established connections, in use and idle.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("in_use_connections", "Connections currently in use.", func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("idle_connections", "Idle connections.", func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("wait_count_total", "Connections waited for.", func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("wait_duration_seconds_total", "Time blocked waiting for a connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+		counter("max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }),
+		counter("max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }),
+	)
+}
+
+type Operation interface {
+	DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error)
+	WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error)
+}
+
+// InstrumentedOperation считает пополнения и списания, проходящие через
+// обработчик transaction.WalletOperation.
+type InstrumentedOperation struct {
+	next    Operation
+	metrics *Metrics
+}
+
+func InstrumentOperation(next Operation, m *Metrics) *InstrumentedOperation {
+	return &InstrumentedOperation{next: next, metrics: m}
+}
+
+func (o *InstrumentedOperation) DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	result, err := o.next.DepositWallet(ctx, walletID, amount, currency, meta)
+	o.metrics.ObserveOperation(postgresql.OperationDeposit, amount, currency, result.Replayed, err)
+	return result, err
+}
+
+func (o *InstrumentedOperation) WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	result, err := o.next.WithdrawWallet(ctx, walletID, amount, currency, meta)
+	o.metrics.ObserveOperation(postgresql.OperationWithdraw, amount, currency, result.Replayed, err)
+	return result, err
+}
+
+type Batcher interface {
+	Operation
+	ApplyBatch(ctx context.Context, items []postgresql.BatchItem, meta postgresql.OperationMeta) ([]postgresql.OperationResult, error)
+}
+
+// InstrumentedBatch дополнительно считает элементы атомарных пакетов.
+type InstrumentedBatch struct {
+	*InstrumentedOperation
+	next Batcher
+}
+
+func InstrumentBatch(next Batcher, m *Metrics) *InstrumentedBatch {
+	return &InstrumentedBatch{InstrumentedOperation: InstrumentOperation(next, m), next: next}
+}
+
+// ApplyBatch учитывает каждый элемент пакета. При ошибке элемента ничего не
+// применено, поэтому считается только он; прочие ошибки относятся ко всем.
+func (o *InstrumentedBatch) ApplyBatch(ctx context.Context, items []postgresql.BatchItem, meta postgresql.OperationMeta) ([]postgresql.OperationResult, error) {
+	results, err := o.next.ApplyBatch(ctx, items, meta)
+
+	var itemErr *postgresql.BatchItemError
+	if errors.As(err, &itemErr) && itemErr.Index < len(items) {
+		item := items[itemErr.Index]
+		o.metrics.ObserveOperation(item.Type, item.Amount, item.Currency, false, err)
+		return results, err
+	}
+
+	// Повтор пакета возвращает все его результаты из сохраненного ответа.
+	replayed := err == nil && len(results) > 0 && results[0].Replayed
+	for _, item := range items {
+		o.metrics.ObserveOperation(item.Type, item.Amount, item.Currency, replayed, err)
+	}
+	return results, err
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"wallet/storage"
+	"wallet/storage/postgresql"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOperation struct {
+	mock.Mock
+}
+
+func (m *MockOperation) DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	args := m.Called(walletID, amount, currency, meta)
+	return args.Get(0).(postgresql.OperationResult), args.Error(1)
+}
+
+func (m *MockOperation) WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta postgresql.OperationMeta) (postgresql.OperationResult, error) {
+	args := m.Called(walletID, amount, currency, meta)
+	return args.Get(0).(postgresql.OperationResult), args.Error(1)
+}
+
+func TestInstrumentOperation(t *testing.T) {
+	m := New()
+	walletID := uuid.New()
+
+	next := new(MockOperation)
+	next.On("DepositWallet", walletID, int64(150), "USD", mock.Anything).Return(postgresql.OperationResult{}, nil)
+	next.On("DepositWallet", walletID, int64(70), "USD", mock.Anything).Return(postgresql.OperationResult{Replayed: true}, nil)
+	next.On("WithdrawWallet", walletID, int64(500), "USD", mock.Anything).Return(postgresql.OperationResult{}, fmt.Errorf("wrapped: %w", storage.ErrInsufficientFunds))
+	next.On("WithdrawWallet", walletID, int64(10), "USD", mock.Anything).Return(postgresql.OperationResult{}, storage.ErrWalletNotFound)
+
+	op := InstrumentOperation(next, m)
+	_, _ = op.DepositWallet(context.Background(), walletID, 150, "USD", postgresql.OperationMeta{})
+	_, _ = op.DepositWallet(context.Background(), walletID, 70, "USD", postgresql.OperationMeta{IdempotencyKey: "k1"})
+	_, _ = op.WithdrawWallet(context.Background(), walletID, 500, "USD", postgresql.OperationMeta{})
+	_, _ = op.WithdrawWallet(context.Background(), walletID, 10, "USD", postgresql.OperationMeta{})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationDeposit, OutcomeOK)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationDeposit, OutcomeReplay)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationWithdraw, OutcomeInsufficientFunds)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationWithdraw, OutcomeNotFound)))
+	assert.Equal(t, 150.0, testutil.ToFloat64(m.Amount.WithLabelValues(postgresql.OperationDeposit, "USD")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.Amount.WithLabelValues(postgresql.OperationWithdraw, "USD")))
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	m := New()
+	m.RegisterDBStats(func() sql.DBStats { return sql.DBStats{OpenConnections: 3, InUse: 2, Idle: 1} })
+
+	n, err := testutil.GatherAndCount(m.Registry, "wallet_db_open_connections", "wallet_db_in_use_connections")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+type MockBatcher struct {
+	MockOperation
+}
+
+func (m *MockBatcher) ApplyBatch(ctx context.Context, items []postgresql.BatchItem, meta postgresql.OperationMeta) ([]postgresql.OperationResult, error) {
+	args := m.Called(items, meta)
+	return args.Get(0).([]postgresql.OperationResult), args.Error(1)
+}
+
+func TestInstrumentBatch(t *testing.T) {
+	m := New()
+	walletID := uuid.New()
+
+	ok := []postgresql.BatchItem{
+		{WalletID: walletID, Type: postgresql.OperationDeposit, Amount: 100, Currency: "USD"},
+		{WalletID: walletID, Type: postgresql.OperationWithdraw, Amount: 30, Currency: "USD"},
+	}
+	failed := []postgresql.BatchItem{
+		{WalletID: walletID, Type: postgresql.OperationDeposit, Amount: 5, Currency: "USD"},
+		{WalletID: walletID, Type: postgresql.OperationWithdraw, Amount: 900, Currency: "USD"},
+	}
+
+	next := new(MockBatcher)
+	next.On("ApplyBatch", ok, postgresql.OperationMeta{}).Return([]postgresql.OperationResult{{}, {}}, nil)
+	next.On("ApplyBatch", ok, postgresql.OperationMeta{IdempotencyKey: "k1"}).Return([]postgresql.OperationResult{{Replayed: true}, {Replayed: true}}, nil)
+	next.On("ApplyBatch", failed, mock.Anything).Return([]postgresql.OperationResult(nil), &postgresql.BatchItemError{Index: 1, Err: storage.ErrInsufficientFunds})
+
+	op := InstrumentBatch(next, m)
+	_, _ = op.ApplyBatch(context.Background(), ok, postgresql.OperationMeta{})
+	_, _ = op.ApplyBatch(context.Background(), ok, postgresql.OperationMeta{IdempotencyKey: "k1"})
+	_, _ = op.ApplyBatch(context.Background(), failed, postgresql.OperationMeta{})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationDeposit, OutcomeOK)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationWithdraw, OutcomeOK)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationDeposit, OutcomeReplay)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationWithdraw, OutcomeReplay)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Operations.WithLabelValues(postgresql.OperationWithdraw, OutcomeInsufficientFunds)))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.Amount.WithLabelValues(postgresql.OperationDeposit, "USD")))
+	assert.Equal(t, 30.0, testutil.ToFloat64(m.Amount.WithLabelValues(postgresql.OperationWithdraw, "USD")))
+}
@@ -0,0 +1,138 @@
+package rates
+
+import (
+	"fmt"
+	"math/big"
+
+	"wallet/internal/lib/currency"
+)
+
+type Rounding string
+
+const (
+	RoundDown     Rounding = "down"      // к нулю
+	RoundUp       Rounding = "up"        // от нуля
+	RoundHalfUp   Rounding = "half_up"   // половина — от нуля
+	RoundHalfEven Rounding = "half_even" // половина — к четному (банковское)
+)
+
+func ParseRounding(s string) (Rounding, error) {
+	switch r := Rounding(s); r {
+	case RoundDown, RoundUp, RoundHalfUp, RoundHalfEven:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode %q", s)
+	}
+}
+
+// Conversion — результат пересчета: Amount в минимальных единицах целевой
+// валюты и курс, по которому он получен.
+type Conversion struct {
+	Amount int64
+	Rate   string
+}
+
+type Converter struct {
+	provider RateProvider
+	rounding Rounding
+}
+
+func NewConverter(provider RateProvider, rounding Rounding) *Converter {
+	return &Converter{provider: provider, rounding: rounding}
+}
+
+// Convert пересчитывает amount (минимальные единицы from) в минимальные
+// единицы to с учетом экспонент валют и режима округления.
+func (c *Converter) Convert(amount int64, from, to string) (Conversion, error) {
+	if from == to {
+		return Conversion{Amount: amount}, nil
+	}
+
+	q, err := c.provider.Rate(from, to)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	converted, err := convert(amount, q, c.rounding)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if amount != 0 && converted == 0 {
+		return Conversion{}, ErrAmountTooSmall
+	}
+
+	return Conversion{Amount: converted, Rate: q.Rate}, nil
+}
+
+func convert(amount int64, q Quote, mode Rounding) (int64, error) {
+	expFrom, ok := currency.Exponent(q.From)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", q.From, ErrUnsupportedCurrency)
+	}
+	expTo, ok := currency.Exponent(q.To)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", q.To, ErrUnsupportedCurrency)
+	}
+
+	rate, ok := new(big.Rat).SetString(q.Rate)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q", q.Rate)
+	}
+
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(expTo-expFrom))), nil))
+	if expTo >= expFrom {
+		v.Mul(v, scale)
+	} else {
+		v.Quo(v, scale)
+	}
+
+	result := round(v, mode)
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows int64")
+	}
+
+	return result.Int64(), nil
+}
+
+func round(v *big.Rat, mode Rounding) *big.Int {
+	q, r := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	away := func() *big.Int {
+		return q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+
+	switch mode {
+	case RoundDown:
+		return q
+	case RoundUp:
+		return away()
+	}
+
+	// Сравниваем остаток с половиной делителя: 2|r| ? den.
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+
+	switch twice.Cmp(v.Denom()) {
+	case 1:
+		return away()
+	case -1:
+		return q
+	}
+
+	if mode == RoundHalfEven && q.Bit(0) == 0 {
+		return q
+	}
+	return away()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
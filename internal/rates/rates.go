@@ -0,0 +1,110 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"wallet/internal/lib/currency"
+)
+
+var (
+	ErrRateNotFound        = errors.New("exchange rate not found")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrAmountTooSmall      = errors.New("converted amount rounds to zero")
+)
+
+// Quote — курс: одна единица From стоит Rate единиц To.
+type Quote struct {
+	From string
+	To   string
+	Rate string
+}
+
+type RateProvider interface {
+	Rate(from, to string) (Quote, error)
+}
+
+// StaticProvider отдает курсы из фиксированной таблицы. Обратные курсы не
+// вычисляются: каждое направление задается явно.
+type StaticProvider struct {
+	rates map[string]Quote
+}
+
+type fileRate struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	Rate string `yaml:"rate"`
+}
+
+type file struct {
+	Rates []fileRate `yaml:"rates"`
+}
+
+func NewStatic(quotes ...Quote) (*StaticProvider, error) {
+	p := &StaticProvider{rates: make(map[string]Quote, len(quotes))}
+
+	for _, q := range quotes {
+		q.From = strings.ToUpper(q.From)
+		q.To = strings.ToUpper(q.To)
+
+		if !currency.IsSupported(q.From) || !currency.IsSupported(q.To) {
+			return nil, fmt.Errorf("rate %s/%s: %w", q.From, q.To, ErrUnsupportedCurrency)
+		}
+
+		rate, ok := new(big.Rat).SetString(q.Rate)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("rate %s/%s: invalid value %q", q.From, q.To, q.Rate)
+		}
+
+		p.rates[pairKey(q.From, q.To)] = q
+	}
+
+	return p, nil
+}
+
+// LoadFile читает курсы из YAML:
+//
+//	rates:
+//	  - from: USD
+//	    to: EUR
+//	    rate: "0.9215"
+func LoadFile(path string) (*StaticProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates file: %w", err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse rates file: %w", err)
+	}
+
+	quotes := make([]Quote, 0, len(f.Rates))
+	for _, r := range f.Rates {
+		quotes = append(quotes, Quote{From: r.From, To: r.To, Rate: r.Rate})
+	}
+
+	return NewStatic(quotes...)
+}
+
+func (p *StaticProvider) Rate(from, to string) (Quote, error) {
+	if from == to {
+		return Quote{From: from, To: to, Rate: "1"}, nil
+	}
+
+	q, ok := p.rates[pairKey(from, to)]
+	if !ok {
+		return Quote{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+	}
+
+	return q, nil
+}
+
+func pairKey(from, to string) string {
+	return from + "/" + to
+}
@@ -0,0 +1,102 @@
+package rates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		quote    Quote
+		mode     Rounding
+		expected int64
+	}{
+		{name: "same exponent", amount: 10000, quote: Quote{From: "USD", To: "EUR", Rate: "0.92"}, mode: RoundHalfEven, expected: 9200},
+		{name: "to zero exponent", amount: 1000, quote: Quote{From: "USD", To: "JPY", Rate: "151.235"}, mode: RoundHalfUp, expected: 1512},
+		{name: "from zero exponent", amount: 1500, quote: Quote{From: "JPY", To: "USD", Rate: "0.0066"}, mode: RoundHalfUp, expected: 990},
+		{name: "half up", amount: 25, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundHalfUp, expected: 13},
+		{name: "half even rounds to even", amount: 25, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundHalfEven, expected: 12},
+		{name: "half even rounds away from odd", amount: 35, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundHalfEven, expected: 18},
+		{name: "down", amount: 199, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundDown, expected: 99},
+		{name: "up", amount: 199, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundUp, expected: 100},
+		{name: "negative half up", amount: -25, quote: Quote{From: "USD", To: "EUR", Rate: "0.5"}, mode: RoundHalfUp, expected: -13},
+		{name: "three digit exponent", amount: 100, quote: Quote{From: "USD", To: "KWD", Rate: "0.3071"}, mode: RoundHalfEven, expected: 307},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convert(tt.amount, tt.quote, tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestConverter(t *testing.T) {
+	provider, err := NewStatic(Quote{From: "usd", To: "eur", Rate: "0.9"})
+	require.NoError(t, err)
+
+	c := NewConverter(provider, RoundHalfEven)
+
+	conv, err := c.Convert(1000, "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, Conversion{Amount: 900, Rate: "0.9"}, conv)
+
+	conv, err = c.Convert(1000, "USD", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, Conversion{Amount: 1000}, conv)
+
+	_, err = c.Convert(1000, "EUR", "USD")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = c.Convert(1, "USD", "EUR")
+	assert.NoError(t, err)
+
+	tiny, err := NewStatic(Quote{From: "USD", To: "EUR", Rate: "0.001"})
+	require.NoError(t, err)
+	_, err = NewConverter(tiny, RoundDown).Convert(1, "USD", "EUR")
+	assert.ErrorIs(t, err, ErrAmountTooSmall)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rates:
+  - from: USD
+    to: EUR
+    rate: "0.9215"
+  - from: EUR
+    to: USD
+    rate: "1.0852"
+`), 0o600))
+
+	provider, err := LoadFile(path)
+	require.NoError(t, err)
+
+	q, err := provider.Rate("EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0852", q.Rate)
+
+	require.NoError(t, os.WriteFile(path, []byte("rates:\n  - {from: USD, to: EUR, rate: \"-1\"}\n"), 0o600))
+	_, err = LoadFile(path)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("rates:\n  - {from: USD, to: XAU, rate: \"1\"}\n"), 0o600))
+	_, err = LoadFile(path)
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestParseRounding(t *testing.T) {
+	r, err := ParseRounding("half_up")
+	require.NoError(t, err)
+	assert.Equal(t, RoundHalfUp, r)
+
+	_, err = ParseRounding("ceil")
+	assert.Error(t, err)
+}
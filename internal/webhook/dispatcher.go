@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wallet/internal/lib/logger/sl"
+	"wallet/storage/postgresql"
+)
+
+// Заголовки запроса к получателю. Подпись — HMAC-SHA256 от
+// "<timestamp>.<тело>" на секрете вебхука, в виде "sha256=<hex>".
+const (
+	HeaderEvent     = "X-Wallet-Event"
+	HeaderEventID   = "X-Wallet-Event-Id"
+	HeaderDelivery  = "X-Wallet-Delivery"
+	HeaderTimestamp = "X-Wallet-Timestamp"
+	HeaderSignature = "X-Wallet-Signature"
+)
+
+// maxErrorBody — сколько байт ответа получателя сохраняется в last_error.
+const maxErrorBody = 512
+
+type Store interface {
+	FanOutEvents(ctx context.Context, limit int) (int, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]postgresql.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, deliveryID uuid.UUID, statusCode int) error
+	MarkFailed(ctx context.Context, deliveryID uuid.UUID, failure postgresql.DeliveryFailure) error
+}
+
+type Config struct {
+	Interval    time.Duration
+	BatchSize   int
+	Workers     int
+	Timeout     time.Duration
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	log    *slog.Logger
+	cfg    Config
+	now    func() time.Time
+}
+
+func NewDispatcher(log *slog.Logger, store Store, cfg Config) *Dispatcher {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: cfg.Timeout},
+		log:    log.With(slog.String("component", "webhook/dispatcher")),
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// Run раскладывает и доставляет события раз в cfg.Interval, пока не отменен ctx.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			d.log.Error("failed to dispatch webhooks", sl.Err(err))
+		}
+	}
+}
+
+// RunOnce раскладывает все новые события и выполняет одну пачку доставок.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	for {
+		n, err := d.store.FanOutEvents(ctx, d.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("fan out: %w", err)
+		}
+		if n < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	// lease с запасом на все попытки пачки: доставки не должны успеть
+	// вернуться в очередь, пока их еще отправляет этот экземпляр.
+	lease := d.cfg.Timeout*time.Duration(d.cfg.BatchSize/d.cfg.Workers+1) + time.Minute
+
+	deliveries, err := d.store.ClaimDeliveries(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		return fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	jobs := make(chan postgresql.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range jobs {
+				d.deliver(ctx, delivery)
+			}
+		}()
+	}
+	for _, delivery := range deliveries {
+		jobs <- delivery
+	}
+	close(jobs)
+	wg.Wait()
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery postgresql.WebhookDelivery) {
+	log := d.log.With(
+		slog.String("delivery_id", delivery.DeliveryID.String()),
+		slog.String("webhook_id", delivery.WebhookID.String()),
+		slog.Int("attempt", delivery.Attempts),
+	)
+
+	statusCode, err := d.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Остановка сервиса: доставка вернется в очередь по истечении lease.
+		return
+	}
+
+	if err == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.DeliveryID, statusCode); err != nil {
+			log.Error("failed to mark delivery as delivered", sl.Err(err))
+		}
+		return
+	}
+
+	failure := postgresql.DeliveryFailure{
+		StatusCode: statusCode,
+		Error:      err.Error(),
+		RetryAt:    d.now().Add(Backoff(delivery.Attempts, d.cfg.BackoffBase, d.cfg.BackoffMax)),
+		Dead:       delivery.Attempts >= d.cfg.MaxAttempts,
+	}
+
+	if failure.Dead {
+		log.Error("webhook delivery moved to dead letter", sl.Err(err))
+	} else {
+		log.Warn("webhook delivery failed", sl.Err(err), slog.Time("retry_at", failure.RetryAt))
+	}
+
+	if err := d.store.MarkFailed(ctx, delivery.DeliveryID, failure); err != nil {
+		log.Error("failed to mark delivery as failed", sl.Err(err))
+	}
+}
+
+// send отправляет событие. Успех — любой ответ 2xx.
+func (d *Dispatcher) send(ctx context.Context, delivery postgresql.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID.String())
+	req.Header.Set(HeaderDelivery, delivery.DeliveryID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign — значение заголовка X-Wallet-Signature.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись на стороне получателя.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff — пауза после attempt-й неудачной попытки: base, 2*base, 4*base...
+// но не больше max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max || delay <= 0 {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
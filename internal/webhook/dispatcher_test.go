@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wallet/storage/postgresql"
+)
+
+// memoryStore — очередь доставок в памяти с той же семантикой попыток,
+// что и в Postgres: ClaimDeliveries увеличивает attempts.
+type memoryStore struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	deliveries map[uuid.UUID]*postgresql.WebhookDelivery
+	fannedOut  int
+}
+
+func newMemoryStore(now func() time.Time, deliveries ...postgresql.WebhookDelivery) *memoryStore {
+	s := &memoryStore{now: now, deliveries: map[uuid.UUID]*postgresql.WebhookDelivery{}}
+	for i := range deliveries {
+		d := deliveries[i]
+		d.Status = postgresql.DeliveryPending
+		d.NextAttemptAt = now()
+		s.deliveries[d.DeliveryID] = &d
+	}
+	return s
+}
+
+func (s *memoryStore) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fannedOut++
+	return 0, nil
+}
+
+func (s *memoryStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]postgresql.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []postgresql.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status != postgresql.DeliveryPending || d.NextAttemptAt.After(s.now()) || len(claimed) == limit {
+			continue
+		}
+		d.Attempts++
+		d.NextAttemptAt = s.now().Add(lease)
+		claimed = append(claimed, *d)
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkDelivered(ctx context.Context, deliveryID uuid.UUID, statusCode int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[deliveryID]
+	d.Status = postgresql.DeliveryDelivered
+	d.LastStatusCode = statusCode
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(ctx context.Context, deliveryID uuid.UUID, failure postgresql.DeliveryFailure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.deliveries[deliveryID]
+	d.LastStatusCode = failure.StatusCode
+	d.LastError = failure.Error
+	d.NextAttemptAt = failure.RetryAt
+	if failure.Dead {
+		d.Status = postgresql.DeliveryDead
+	}
+	return nil
+}
+
+func (s *memoryStore) get(id uuid.UUID) postgresql.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[id]
+}
+
+// clock — время, которое тест двигает вручную, чтобы не ждать backoff.
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newDispatcher(store Store, clk *clock) *Dispatcher {
+	d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), store, Config{
+		Interval:    10 * time.Millisecond,
+		BatchSize:   10,
+		Workers:     2,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		BackoffBase: time.Second,
+		BackoffMax:  time.Minute,
+	})
+	d.now = clk.Now
+	return d
+}
+
+func newDelivery(url string) postgresql.WebhookDelivery {
+	return postgresql.WebhookDelivery{
+		DeliveryID: uuid.New(),
+		WebhookID:  uuid.New(),
+		EventID:    uuid.New(),
+		URL:        url,
+		Secret:     "s3cret",
+		EventType:  postgresql.EventBalanceChanged,
+		Payload:    []byte(`{"type":"wallet.balance_changed","amount":100}`),
+	}
+}
+
+func TestDispatcherDeliversSignedEvent(t *testing.T) {
+	clk := &clock{t: time.Unix(1700000000, 0)}
+
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("s3cret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "1700000000", r.Header.Get(HeaderTimestamp))
+		assert.Equal(t, postgresql.EventBalanceChanged, r.Header.Get(HeaderEvent))
+		assert.JSONEq(t, `{"type":"wallet.balance_changed","amount":100}`, string(body))
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	delivery := newDelivery(receiver.URL)
+	store := newMemoryStore(clk.Now, delivery)
+
+	require.NoError(t, newDispatcher(store, clk).RunOnce(context.Background()))
+
+	assert.Equal(t, int32(1), received.Load())
+	got := store.get(delivery.DeliveryID)
+	assert.Equal(t, postgresql.DeliveryDelivered, got.Status)
+	assert.Equal(t, http.StatusNoContent, got.LastStatusCode)
+	assert.Equal(t, 1, store.fannedOut)
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	clk := &clock{t: time.Unix(1700000000, 0)}
+
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	delivery := newDelivery(receiver.URL)
+	store := newMemoryStore(clk.Now, delivery)
+	dispatcher := newDispatcher(store, clk)
+
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+
+	got := store.get(delivery.DeliveryID)
+	assert.Equal(t, postgresql.DeliveryPending, got.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, got.LastStatusCode)
+	assert.Contains(t, got.LastError, "temporarily unavailable")
+	assert.Equal(t, clk.Now().Add(time.Second), got.NextAttemptAt)
+
+	// До истечения паузы повторной попытки нет.
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+	assert.Equal(t, int32(1), calls.Load())
+
+	clk.Advance(time.Second)
+	require.NoError(t, dispatcher.RunOnce(context.Background()))
+
+	got = store.get(delivery.DeliveryID)
+	assert.Equal(t, postgresql.DeliveryDelivered, got.Status)
+	assert.Equal(t, 2, got.Attempts)
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	clk := &clock{t: time.Unix(1700000000, 0)}
+
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	delivery := newDelivery(receiver.URL)
+	store := newMemoryStore(clk.Now, delivery)
+	dispatcher := newDispatcher(store, clk)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, dispatcher.RunOnce(context.Background()))
+		clk.Advance(time.Hour)
+	}
+
+	got := store.get(delivery.DeliveryID)
+	assert.Equal(t, postgresql.DeliveryDead, got.Status)
+	assert.Equal(t, 3, got.Attempts)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDispatcherRunStopsOnCancel(t *testing.T) {
+	clk := &clock{t: time.Unix(1700000000, 0)}
+	store := newMemoryStore(clk.Now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newDispatcher(store, clk).Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 5 * time.Second
+	max := time.Minute
+
+	assert.Equal(t, 5*time.Second, Backoff(1, base, max))
+	assert.Equal(t, 10*time.Second, Backoff(2, base, max))
+	assert.Equal(t, 40*time.Second, Backoff(4, base, max))
+	assert.Equal(t, time.Minute, Backoff(5, base, max))
+	assert.Equal(t, time.Minute, Backoff(100, base, max))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"amount":1}`)
+	signature := Sign("key", "123", body)
+
+	assert.True(t, Verify("key", "123", body, signature))
+	assert.False(t, Verify("other", "123", body, signature))
+	assert.False(t, Verify("key", "124", body, signature))
+	assert.False(t, Verify("key", "123", []byte(`{"amount":2}`), signature))
+}
@@ -0,0 +1,11 @@
+-- Лимиты кошелька. NULL — без ограничения. Суточный и месячный лимиты
+-- считаются по календарным суткам и месяцам UTC.
+CREATE TABLE wallet_limits (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets (wallet_id),
+    max_withdrawal BIGINT CHECK (max_withdrawal >= 0),
+    daily_withdrawal BIGINT CHECK (daily_withdrawal >= 0),
+    monthly_withdrawal BIGINT CHECK (monthly_withdrawal >= 0),
+    max_balance BIGINT CHECK (max_balance >= 0),
+    updated_by TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
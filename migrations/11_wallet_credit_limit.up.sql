@@ -0,0 +1,2 @@
+-- Кредитная линия: баланс может опускаться до -credit_limit.
+ALTER TABLE wallets ADD COLUMN credit_limit BIGINT NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
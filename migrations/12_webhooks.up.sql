@@ -0,0 +1,42 @@
+-- Transactional outbox: событие пишется в той же транзакции, что и изменение
+-- баланса. Диспетчер раскладывает события по вебхукам (dispatched_at) и
+-- доставляет их с повторами.
+CREATE TABLE wallet_events (
+    seq BIGSERIAL UNIQUE,
+    event_id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id),
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX wallet_events_pending_idx ON wallet_events (seq) WHERE dispatched_at IS NULL;
+
+-- owner_id NULL — события всех кошельков.
+CREATE TABLE webhooks (
+    webhook_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    owner_id TEXT,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries (
+    delivery_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks (webhook_id),
+    event_id UUID NOT NULL REFERENCES wallet_events (event_id),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'DEAD')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS journal_postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP FUNCTION IF EXISTS journal_entry_balanced();
+DROP FUNCTION IF EXISTS journal_immutable();
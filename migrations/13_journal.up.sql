@@ -0,0 +1,75 @@
+-- Двойная запись: каждое движение денег — проводка (journal_entries) из
+-- нескольких записей по счетам (journal_postings). amount со знаком:
+-- положительный увеличивает остаток счета. Сумма записей проводки по каждой
+-- валюте равна нулю.
+CREATE TABLE journal_entries (
+    seq BIGSERIAL UNIQUE,
+    entry_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_type TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE journal_postings (
+    posting_id BIGSERIAL PRIMARY KEY,
+    entry_id UUID NOT NULL REFERENCES journal_entries (entry_id),
+    account TEXT NOT NULL,
+    amount NUMERIC NOT NULL CHECK (amount <> 0),
+    currency CHAR(3) NOT NULL
+);
+
+CREATE INDEX journal_postings_entry_idx ON journal_postings (entry_id);
+CREATE INDEX journal_postings_account_idx ON journal_postings (account, posting_id DESC);
+
+CREATE FUNCTION journal_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION journal_immutable();
+
+CREATE TRIGGER journal_postings_immutable
+    BEFORE UPDATE OR DELETE ON journal_postings
+    FOR EACH ROW EXECUTE FUNCTION journal_immutable();
+
+-- Баланс проверяет и сервис; триггер страхует от записи в обход него.
+CREATE FUNCTION journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM journal_postings
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER journal_postings_balanced
+    AFTER INSERT ON journal_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION journal_entry_balanced();
+
+-- Существующие балансы переносим проводками OPENING с внешнего счета, чтобы
+-- остатки счетов wallet:<id> сходились с wallets.balance.
+WITH opening AS MATERIALIZED (
+    SELECT gen_random_uuid() AS entry_id, wallet_id, balance, currency
+    FROM wallets
+    WHERE COALESCE(balance, 0) <> 0
+), entries AS (
+    INSERT INTO journal_entries (entry_id, entry_type, request_id)
+    SELECT entry_id, 'OPENING', 'migration'
+    FROM opening
+)
+INSERT INTO journal_postings (entry_id, account, amount, currency)
+SELECT o.entry_id, p.account, p.amount, o.currency
+FROM opening o
+CROSS JOIN LATERAL (VALUES
+    ('wallet:' || o.wallet_id, o.balance),
+    ('external:bank', -o.balance)
+) AS p (account, amount);
@@ -0,0 +1,2 @@
+-- Тариф кошелька: по нему выбираются правила комиссий (см. FEES_PATH).
+ALTER TABLE wallets ADD COLUMN tier TEXT NOT NULL DEFAULT 'default' CHECK (tier <> '');
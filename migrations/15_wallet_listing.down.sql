@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS wallets_created_at_idx;
+DROP INDEX IF EXISTS wallets_balance_idx;
+DROP INDEX IF EXISTS wallets_owner_wallet_idx;
+CREATE INDEX IF NOT EXISTS wallets_owner_idx ON wallets (owner_id);
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS created_at;
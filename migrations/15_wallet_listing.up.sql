@@ -0,0 +1,24 @@
+-- Время создания кошелька. Существующим кошелькам ставим время первой
+-- операции, а тем, у кого операций нет, — время миграции.
+ALTER TABLE wallets ADD COLUMN created_at TIMESTAMPTZ;
+
+UPDATE wallets w
+SET created_at = o.first_at
+FROM (
+    SELECT wallet_id, MIN(created_at) AS first_at
+    FROM wallet_operations
+    GROUP BY wallet_id
+) o
+WHERE o.wallet_id = w.wallet_id;
+
+UPDATE wallets SET created_at = now() WHERE created_at IS NULL;
+
+ALTER TABLE wallets ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE wallets ALTER COLUMN created_at SET NOT NULL;
+
+-- Индексы для постраничной выборки: ключ сортировки и wallet_id как
+-- уточнение для курсора.
+DROP INDEX IF EXISTS wallets_owner_idx;
+CREATE INDEX wallets_owner_wallet_idx ON wallets (owner_id, wallet_id);
+CREATE INDEX wallets_balance_idx ON wallets (balance, wallet_id);
+CREATE INDEX wallets_created_at_idx ON wallets (created_at, wallet_id);
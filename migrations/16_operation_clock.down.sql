@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS wallet_operations_wallet_created_idx;
+CREATE INDEX wallet_operations_wallet_created_idx ON wallet_operations (wallet_id, created_at);
+
+ALTER TABLE wallet_operations ALTER COLUMN created_at SET DEFAULT now();
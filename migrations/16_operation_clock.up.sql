@@ -0,0 +1,8 @@
+-- now() — время начала транзакции: при параллельных операциях оно не
+-- совпадает с порядком seq. clock_timestamp() берется в момент вставки, а
+-- операции кошелька пишутся под блокировкой его строки, поэтому created_at
+-- внутри кошелька растет вместе с seq.
+ALTER TABLE wallet_operations ALTER COLUMN created_at SET DEFAULT clock_timestamp();
+
+DROP INDEX IF EXISTS wallet_operations_wallet_created_idx;
+CREATE INDEX wallet_operations_wallet_created_idx ON wallet_operations (wallet_id, created_at, seq);
@@ -0,0 +1,13 @@
+-- Журнал изменений кредитной линии: кто и когда разрешил кошельку уходить
+-- в минус.
+CREATE TABLE wallet_credit_limit_changes (
+    change_id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id),
+    from_limit BIGINT NOT NULL,
+    to_limit BIGINT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_credit_limit_changes_wallet_idx ON wallet_credit_limit_changes (wallet_id, change_id);
@@ -0,0 +1 @@
+ALTER TABLE wallets ADD COLUMN owner_id TEXT;
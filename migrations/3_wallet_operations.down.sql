@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_operations;
+DROP FUNCTION IF EXISTS wallet_operations_immutable();
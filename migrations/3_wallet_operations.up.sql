@@ -0,0 +1,30 @@
+CREATE TABLE wallet_operations (
+    seq BIGSERIAL UNIQUE,
+    operation_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id),
+    operation_type TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    balance_after NUMERIC NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_operations_wallet_seq_idx ON wallet_operations (wallet_id, seq DESC);
+CREATE INDEX wallet_operations_wallet_created_idx ON wallet_operations (wallet_id, created_at);
+
+-- Журнал только дописывается: исправления оформляются новыми операциями.
+CREATE FUNCTION wallet_operations_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet_operations is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallet_operations_immutable
+    BEFORE UPDATE OR DELETE ON wallet_operations
+    FOR EACH ROW EXECUTE FUNCTION wallet_operations_immutable();
+
+-- Существующие балансы переносим в журнал, чтобы история сходилась с wallets.balance.
+INSERT INTO wallet_operations (wallet_id, operation_type, amount, balance_after)
+SELECT wallet_id, 'OPENING', balance, balance
+FROM wallets
+WHERE COALESCE(balance, 0) <> 0;
@@ -0,0 +1,7 @@
+ALTER TABLE wallet_operations DROP COLUMN IF EXISTS currency;
+ALTER TABLE wallet_operations ALTER COLUMN balance_after TYPE NUMERIC;
+ALTER TABLE wallet_operations ALTER COLUMN amount TYPE NUMERIC;
+
+ALTER TABLE wallets ALTER COLUMN balance DROP NOT NULL;
+ALTER TABLE wallets ALTER COLUMN balance TYPE NUMERIC;
+ALTER TABLE wallets DROP COLUMN IF EXISTS currency;
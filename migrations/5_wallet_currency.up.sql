@@ -0,0 +1,13 @@
+-- До этой миграции валюта не хранилась: существующие кошельки считаем
+-- долларовыми, а их балансы — суммами в центах.
+ALTER TABLE wallets ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE wallets ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE wallets ALTER COLUMN balance TYPE BIGINT USING COALESCE(balance, 0)::BIGINT;
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0;
+ALTER TABLE wallets ALTER COLUMN balance SET NOT NULL;
+
+ALTER TABLE wallet_operations ALTER COLUMN amount TYPE BIGINT USING amount::BIGINT;
+ALTER TABLE wallet_operations ALTER COLUMN balance_after TYPE BIGINT USING balance_after::BIGINT;
+ALTER TABLE wallet_operations ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE wallet_operations ALTER COLUMN currency DROP DEFAULT;
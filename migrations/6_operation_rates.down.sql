@@ -0,0 +1 @@
+ALTER TABLE wallet_operations DROP COLUMN IF EXISTS rate;
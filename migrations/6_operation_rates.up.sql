@@ -0,0 +1,2 @@
+-- Курс, по которому выполнена операция перевода между разными валютами.
+ALTER TABLE wallet_operations ADD COLUMN rate NUMERIC;
@@ -0,0 +1,18 @@
+-- held — сумма активных холдов. Доступный остаток: balance - held.
+ALTER TABLE wallets ADD COLUMN held BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE wallet_holds (
+    hold_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'ACTIVE',
+    request_id TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_holds_wallet_idx ON wallet_holds (wallet_id);
+CREATE INDEX wallet_holds_active_expiry_idx ON wallet_holds (expires_at) WHERE status = 'ACTIVE';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallets_owner_idx;
+DROP TABLE IF EXISTS api_clients;
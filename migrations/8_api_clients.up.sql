@@ -0,0 +1,11 @@
+-- Ключи хранятся только в виде SHA-256. Кошельки клиента — wallets.owner_id = client_id.
+CREATE TABLE api_clients (
+    client_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX wallets_owner_idx ON wallets (owner_id);
@@ -0,0 +1,17 @@
+-- ACTIVE — обычный кошелек, FROZEN — операции запрещены, CLOSED — закрыт навсегда.
+ALTER TABLE wallets
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('ACTIVE', 'FROZEN', 'CLOSED'));
+
+CREATE TABLE wallet_status_changes (
+    change_id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets (wallet_id),
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_status_changes_wallet_idx ON wallet_status_changes (wallet_id, change_id);
@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type BatchItem struct {
+	WalletID uuid.UUID
+	Type     string // OperationDeposit или OperationWithdraw
+	Amount   int64
+	Currency string
+}
+
+// BatchItemError — ошибка элемента пакета; Index — номер элемента в запросе.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyBatch выполняет все пополнения и списания в одной транзакции: при
+// ошибке любого элемента не применяется ни один. Кошельки блокируются заранее
+// в порядке wallet_id, как в переводах. Возвращает состояние кошелька и
+// комиссию после каждого элемента.
+func (sp *StoragePostgresql) ApplyBatch(ctx context.Context, items []BatchItem, meta OperationMeta) (_ []OperationResult, err error) {
+	const fn = "storage.postgresql.ApplyBatch"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, attribute.Int("batch.size", len(items)))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var results []OperationResult
+
+	replayed, err := claimIdempotencyKey(ctx, tx, meta, &results)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if replayed {
+		for i := range results {
+			results[i].Replayed = true
+		}
+		return results, nil
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.WalletID.String())
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		SELECT 1
+		FROM wallets
+		WHERE wallet_id = ANY($1::uuid[])
+		ORDER BY wallet_id
+		FOR UPDATE;
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to lock wallets: %w", fn, err)
+	}
+
+	results = make([]OperationResult, 0, len(items))
+	for i, item := range items {
+		var delta int64
+		switch item.Type {
+		case OperationDeposit:
+			delta = item.Amount
+		case OperationWithdraw:
+			delta = -item.Amount
+		default:
+			return nil, fmt.Errorf("%s: %w", fn, &BatchItemError{Index: i, Err: fmt.Errorf("unsupported operation %q", item.Type)})
+		}
+
+		wallet, fee, err := applyBalanceChange(ctx, tx, item.WalletID, item.Type, delta, item.Currency, sp.fees, meta)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, &BatchItemError{Index: i, Err: err})
+		}
+		results = append(results, OperationResult{Wallet: wallet, Fee: fee})
+	}
+
+	if err := saveIdempotencyResponse(ctx, tx, meta, results); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,76 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"wallet/storage"
+
+	"github.com/lib/pq"
+)
+
+// APIClient — клиент API. Ключ хранится только в виде хеша.
+type APIClient struct {
+	ClientID  string
+	Name      string
+	Scope     string
+	CreatedAt time.Time
+}
+
+func (sp *StoragePostgresql) ClientByKeyHash(ctx context.Context, keyHash string) (_ APIClient, err error) {
+	const fn = "storage.postgresql.ClientByKeyHash"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	stmt, err := sp.db.PrepareContext(ctx, `
+		SELECT client_id, name, scope, created_at
+		FROM api_clients
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`)
+	if err != nil {
+		return APIClient{}, fmt.Errorf("%s: prepare statement: %w", fn, err)
+	}
+
+	var client APIClient
+	err = stmt.QueryRowContext(ctx, keyHash).Scan(&client.ClientID, &client.Name, &client.Scope, &client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIClient{}, storage.ErrClientNotFound
+		}
+		return APIClient{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return client, nil
+}
+
+func (sp *StoragePostgresql) CreateAPIClient(ctx context.Context, client APIClient, keyHash string) (_ APIClient, err error) {
+	const fn = "storage.postgresql.CreateAPIClient"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	stmt, err := sp.db.PrepareContext(ctx, `
+		INSERT INTO api_clients (client_id, name, key_hash, scope)
+		VALUES ($1, $2, $3, $4)
+		RETURNING client_id, name, scope, created_at
+	`)
+	if err != nil {
+		return APIClient{}, fmt.Errorf("%s: prepare statement: %w", fn, err)
+	}
+
+	var created APIClient
+	err = stmt.QueryRowContext(ctx, client.ClientID, client.Name, keyHash, client.Scope).
+		Scan(&created.ClientID, &created.Name, &created.Scope, &created.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+			return APIClient{}, fmt.Errorf("%s: %w", fn, storage.ErrClientExists)
+		}
+		return APIClient{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	return created, nil
+}
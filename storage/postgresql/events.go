@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const EventBalanceChanged = "wallet.balance_changed"
+
+// BalanceChannel — канал LISTEN/NOTIFY, в который при фиксации транзакции
+// уходит BalanceEvent в JSON.
+const BalanceChannel = "wallet_balance"
+
+// BalanceEvent — событие об изменении баланса, которое уходит подписчикам
+// как есть (тело вебхука). Amount — сумма операции со знаком, Fee — взятая
+// за нее комиссия: баланс изменился на Amount - Fee.
+type BalanceEvent struct {
+	EventID       uuid.UUID `json:"event_id"`
+	Type          string    `json:"type"`
+	WalletID      uuid.UUID `json:"wallet_id"`
+	OperationType string    `json:"operation_type"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee,omitempty"`
+	Balance       int64     `json:"balance"`
+	Available     int64     `json:"available"`
+	Currency      string    `json:"currency"`
+	RequestID     string    `json:"request_id,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// writeEvent кладет событие в outbox (wallet_events) и в BalanceChannel.
+// Вызывается в той же транзакции, что и изменение баланса: откат операции
+// отменяет и событие, а NOTIFY доставляется слушателям только после COMMIT.
+func writeEvent(ctx context.Context, tx *sql.Tx, wallet Wallet, opType string, amount, fee int64, meta OperationMeta) (err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.writeEvent", &err, walletAttr(wallet.WalletID), operationAttr(opType))
+	defer end()
+
+	event := BalanceEvent{
+		EventID:       uuid.New(),
+		Type:          EventBalanceChanged,
+		WalletID:      wallet.WalletID,
+		OperationType: opType,
+		Amount:        amount,
+		Fee:           fee,
+		Balance:       wallet.Balance,
+		Available:     wallet.Available(),
+		Currency:      wallet.Currency,
+		RequestID:     meta.RequestID,
+		OccurredAt:    time.Now().UTC(),
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO wallet_events (event_id, wallet_id, event_type, payload)
+		VALUES ($1, $2, $3, $4);
+	`, event.EventID, event.WalletID, event.Type, payload)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", BalanceChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"wallet/storage"
+)
+
+// OperationFee — комиссия за операцию. Пишется в журнал отдельной записью
+// сразу после операции, за которую взята.
+const OperationFee = "FEE"
+
+// FeeSchedule считает комиссию: operation — "DEPOSIT", "WITHDRAW" или
+// "TRANSFER", tier и currency — тариф и валюта кошелька. См. fees.Schedule.
+type FeeSchedule interface {
+	Fee(operation, tier, currency string, amount int64) int64
+}
+
+// OperationResult — кошелек после операции и взятая за нее комиссия.
+// Replayed — ответ взят из сохраненного по ключу идемпотентности, операция
+// повторно не применялась; в сохраненный ответ флаг не попадает.
+type OperationResult struct {
+	Wallet
+	Fee      int64
+	Replayed bool `json:"-"`
+}
+
+// feeOperations — операции журнала, за которые берется комиссия, и их
+// имена в FeeSchedule. Зачисление перевода и списание по холду бесплатны.
+var feeOperations = map[string]string{
+	OperationDeposit:     "DEPOSIT",
+	OperationWithdraw:    "WITHDRAW",
+	OperationTransferOut: "TRANSFER",
+}
+
+// SetFees включает комиссии. Вызывается до начала обработки запросов;
+// без вызова комиссии не берутся.
+func (sp *StoragePostgresql) SetFees(fees FeeSchedule) {
+	sp.fees = fees
+}
+
+// operationFee — комиссия за движение delta по кошельку. Комиссия за
+// пополнение не может превышать его сумму.
+func operationFee(fees FeeSchedule, wallet Wallet, opType string, delta int64) (int64, error) {
+	operation, ok := feeOperations[opType]
+	if fees == nil || !ok {
+		return 0, nil
+	}
+
+	amount := delta
+	if amount < 0 {
+		amount = -amount
+	}
+
+	fee := fees.Fee(operation, wallet.Tier, wallet.Currency, amount)
+	if delta > 0 && fee > delta {
+		return 0, fmt.Errorf("fee %d exceeds amount %d: %w", fee, delta, storage.ErrFeeExceedsAmount)
+	}
+
+	return fee, nil
+}
+
+// recordOperationWithFee пишет операцию и, если fee не нулевая, ее комиссию.
+// balance_after операции — баланс до списания комиссии, чтобы выписка
+// сходилась по каждой записи.
+func recordOperationWithFee(ctx context.Context, tx *sql.Tx, wallet Wallet, opType string, delta, fee int64, meta OperationMeta) error {
+	if fee == 0 {
+		return recordOperation(ctx, tx, wallet, opType, delta, meta)
+	}
+
+	before := wallet
+	before.Balance += fee
+	if err := recordOperation(ctx, tx, before, opType, delta, meta); err != nil {
+		return err
+	}
+
+	feeMeta := meta
+	feeMeta.rate = ""
+	return recordOperation(ctx, tx, wallet, OperationFee, -fee, feeMeta)
+}
+
+// SetWalletTier меняет тариф кошелька. Новые правила действуют для
+// следующих операций.
+func (sp *StoragePostgresql) SetWalletTier(ctx context.Context, walletID uuid.UUID, tier string) (_ Wallet, err error) {
+	const fn = "storage.postgresql.SetWalletTier"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID))
+	defer done()
+
+	wallet, err := scanWallet(sp.db.QueryRowContext(ctx,
+		"UPDATE wallets SET tier = $1 WHERE wallet_id = $2 RETURNING "+walletColumns,
+		tier, walletID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Wallet{}, fmt.Errorf("%s: %w", fn, storage.ErrWalletNotFound)
+		}
+		return Wallet{}, fmt.Errorf("%s: failed to update tier: %w", fn, err)
+	}
+
+	return wallet, nil
+}
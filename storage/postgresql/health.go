@@ -0,0 +1,39 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func (sp *StoragePostgresql) Ping(ctx context.Context) (err error) {
+	const fn = "storage.postgresql.Ping"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	if err := sp.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// SchemaVersion возвращает текущую версию схемы из таблицы schema_migrations
+// (golang-migrate). dirty означает, что последняя миграция упала на середине.
+func (sp *StoragePostgresql) SchemaVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	const fn = "storage.postgresql.SchemaVersion"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	err = sp.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return version, dirty, nil
+}
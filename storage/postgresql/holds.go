@@ -0,0 +1,317 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"wallet/storage"
+)
+
+const (
+	HoldActive   = "ACTIVE"
+	HoldCaptured = "CAPTURED"
+	HoldVoided   = "VOIDED"
+	HoldExpired  = "EXPIRED"
+)
+
+// Hold — резерв средств на кошельке. Пока холд активен, его сумма входит в
+// wallets.held и уменьшает доступный остаток, но не баланс.
+type Hold struct {
+	HoldID         uuid.UUID
+	WalletID       uuid.UUID
+	Amount         int64
+	CapturedAmount int64
+	Currency       string
+	Status         string
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+type HoldResult struct {
+	Hold   Hold
+	Wallet Wallet
+}
+
+const holdColumns = "hold_id, wallet_id, amount, captured_amount, currency, status, expires_at, created_at"
+
+func scanHold(row rowScanner) (Hold, error) {
+	var hold Hold
+	err := row.Scan(
+		&hold.HoldID, &hold.WalletID, &hold.Amount, &hold.CapturedAmount,
+		&hold.Currency, &hold.Status, &hold.ExpiresAt, &hold.CreatedAt,
+	)
+	return hold, err
+}
+
+// CreateHold резервирует amount на кошельке на время ttl. Статус, остаток и
+// лимиты кошелька проверяются здесь, а не при capture.
+func (sp *StoragePostgresql) CreateHold(ctx context.Context, walletID uuid.UUID, amount int64, currency string, ttl time.Duration, meta OperationMeta) (_ HoldResult, err error) {
+	const fn = "storage.postgresql.CreateHold"
+
+	if ttl <= 0 {
+		return HoldResult{}, fmt.Errorf("%s: ttl must be positive, got %s", fn, ttl)
+	}
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var result HoldResult
+
+	replayed, err := claimIdempotencyKey(ctx, tx, meta, &result)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if replayed {
+		return result, nil
+	}
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := checkOwner(wallet, meta.OwnerID); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := checkStatus(wallet); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if wallet.Currency != currency {
+		return HoldResult{}, fmt.Errorf("%s: wallet currency is %s: %w", fn, wallet.Currency, storage.ErrCurrencyMismatch)
+	}
+	if wallet.Available() < amount {
+		return HoldResult{}, fmt.Errorf("%s: insufficient funds: %w", fn, storage.ErrInsufficientFunds)
+	}
+	// Лимиты проверяются сейчас, как для списания: capture их уже не
+	// проверяет.
+	if err := checkLimits(ctx, tx, wallet, OperationCapture, -amount, -amount); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if result.Wallet, err = changeHeld(ctx, tx, walletID, amount); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wallet_holds (wallet_id, amount, currency, request_id, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), now() + $5 * interval '1 millisecond')
+		RETURNING `+holdColumns+`;
+	`)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to prepare statement: %w", fn, err)
+	}
+
+	result.Hold, err = scanHold(stmt.QueryRowContext(ctx, walletID, amount, currency, meta.RequestID, ttl.Milliseconds()))
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to create hold: %w", fn, err)
+	}
+
+	if err := saveIdempotencyResponse(ctx, tx, meta, result); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return result, nil
+}
+
+// CaptureHold списывает amount из холда (0 — всю сумму) и снимает резерв
+// целиком: незахваченный остаток снова становится доступным.
+func (sp *StoragePostgresql) CaptureHold(ctx context.Context, walletID, holdID uuid.UUID, amount int64, meta OperationMeta) (HoldResult, error) {
+	const fn = "storage.postgresql.CaptureHold"
+
+	return sp.closeHold(ctx, fn, walletID, holdID, HoldCaptured, amount, meta)
+}
+
+// VoidHold отменяет холд без списания.
+func (sp *StoragePostgresql) VoidHold(ctx context.Context, walletID, holdID uuid.UUID, meta OperationMeta) (HoldResult, error) {
+	const fn = "storage.postgresql.VoidHold"
+
+	return sp.closeHold(ctx, fn, walletID, holdID, HoldVoided, 0, meta)
+}
+
+func (sp *StoragePostgresql) closeHold(ctx context.Context, fn string, walletID, holdID uuid.UUID, status string, amount int64, meta OperationMeta) (_ HoldResult, err error) {
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID), attribute.String("hold.id", holdID.String()), attribute.String("hold.status", status))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var result HoldResult
+
+	replayed, err := claimIdempotencyKey(ctx, tx, meta, &result)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if replayed {
+		return result, nil
+	}
+
+	// Порядок блокировок везде одинаковый: сначала холд, потом кошелек.
+	hold, err := scanHold(tx.QueryRowContext(ctx,
+		"SELECT "+holdColumns+" FROM wallet_holds WHERE hold_id = $1 AND wallet_id = $2 FOR UPDATE",
+		holdID, walletID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return HoldResult{}, fmt.Errorf("%s: %w", fn, storage.ErrHoldNotFound)
+		}
+		return HoldResult{}, fmt.Errorf("%s: failed to lock hold: %w", fn, err)
+	}
+
+	if hold.Status == HoldExpired || (hold.Status == HoldActive && !hold.ExpiresAt.After(time.Now())) {
+		// Просроченный холд, до которого еще не дошел ExpireHolds, тоже
+		// считается истекшим.
+		return HoldResult{}, fmt.Errorf("%s: hold expired at %s: %w", fn, hold.ExpiresAt.Format(time.RFC3339), storage.ErrHoldExpired)
+	}
+	if hold.Status != HoldActive {
+		return HoldResult{}, fmt.Errorf("%s: hold is %s: %w", fn, hold.Status, storage.ErrHoldNotActive)
+	}
+
+	if status == HoldCaptured && amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, storage.ErrHoldAmountExceeded)
+	}
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := checkOwner(wallet, meta.OwnerID); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if result.Wallet, err = changeHeld(ctx, tx, walletID, -hold.Amount); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// Статус, остаток и лимиты проверены при создании холда, средства с тех
+	// пор зарезервированы: capture их не перепроверяет, иначе одобренный
+	// холд мог бы не списаться.
+	if amount > 0 {
+		result.Wallet, err = writeBalanceChange(ctx, tx, walletID, OperationCapture, -amount, 0, meta)
+		if err != nil {
+			return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	result.Hold, err = scanHold(tx.QueryRowContext(ctx, `
+		UPDATE wallet_holds
+		SET status = $1, captured_amount = $2, updated_at = now()
+		WHERE hold_id = $3
+		RETURNING `+holdColumns,
+		status, amount, holdID,
+	))
+	if err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to update hold: %w", fn, err)
+	}
+
+	if err := saveIdempotencyResponse(ctx, tx, meta, result); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return HoldResult{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return result, nil
+}
+
+// ExpireHolds снимает до limit просроченных холдов и возвращает их число.
+// Холды, которые сейчас захватывает или отменяет другой запрос, пропускаются.
+func (sp *StoragePostgresql) ExpireHolds(ctx context.Context, limit int) (_ int, err error) {
+	const fn = "storage.postgresql.ExpireHolds"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT hold_id, wallet_id, amount
+		FROM wallet_holds
+		WHERE status = 'ACTIVE' AND expires_at <= now()
+		ORDER BY expires_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED;
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to select expired holds: %w", fn, err)
+	}
+
+	var expired []Hold
+	for rows.Next() {
+		var hold Hold
+		if err := rows.Scan(&hold.HoldID, &hold.WalletID, &hold.Amount); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: failed to scan hold: %w", fn, err)
+		}
+		expired = append(expired, hold)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: failed to select expired holds: %w", fn, err)
+	}
+
+	// Кошельки обновляем в порядке wallet_id, как и переводы.
+	sort.Slice(expired, func(i, j int) bool {
+		return bytes.Compare(expired[i].WalletID[:], expired[j].WalletID[:]) < 0
+	})
+
+	for _, hold := range expired {
+		if _, err := changeHeld(ctx, tx, hold.WalletID, -hold.Amount); err != nil {
+			return 0, fmt.Errorf("%s: %w", fn, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE wallet_holds SET status = $1, updated_at = now() WHERE hold_id = $2",
+			HoldExpired, hold.HoldID,
+		); err != nil {
+			return 0, fmt.Errorf("%s: failed to expire hold: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return len(expired), nil
+}
+
+func changeHeld(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, delta int64) (_ Wallet, err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.changeHeld", &err, walletAttr(walletID))
+	defer end()
+
+	wallet, err := scanWallet(tx.QueryRowContext(ctx,
+		"UPDATE wallets SET held = held + $1 WHERE wallet_id = $2 RETURNING "+walletColumns,
+		delta, walletID,
+	))
+	if err != nil {
+		return Wallet{}, fmt.Errorf("failed to update held amount: %w", err)
+	}
+	return wallet, nil
+}
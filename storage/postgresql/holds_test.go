@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wallet/storage"
+)
+
+func createTestWallet(t *testing.T, sp *StoragePostgresql, balance int64) uuid.UUID {
+	t.Helper()
+
+	wallet, err := sp.CreateWallet(context.Background(), Wallet{Balance: balance, Currency: "USD"}, OperationMeta{})
+	require.NoError(t, err)
+	return wallet.WalletID
+}
+
+// Холд одобрен, пока кошелек был активен и в лимитах: capture проходит,
+// даже если после этого кошелек заморожен, а лимит снижен.
+func TestCaptureHoldSkipsChecksOfCreate(t *testing.T) {
+	sp := testStorage(t)
+	ctx := context.Background()
+	walletID := createTestWallet(t, sp, 1000)
+
+	res, err := sp.CreateHold(ctx, walletID, 300, "USD", time.Hour, OperationMeta{})
+	require.NoError(t, err)
+
+	maxWithdrawal := int64(100)
+	_, err = sp.SetWalletLimits(ctx, walletID, Limits{MaxWithdrawal: &maxWithdrawal})
+	require.NoError(t, err)
+	_, err = sp.db.ExecContext(ctx, "UPDATE wallets SET status = $1 WHERE wallet_id = $2", WalletFrozen, walletID)
+	require.NoError(t, err)
+
+	captured, err := sp.CaptureHold(ctx, walletID, res.Hold.HoldID, 0, OperationMeta{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(700), captured.Wallet.Balance)
+	assert.Equal(t, int64(0), captured.Wallet.Held)
+	assert.Equal(t, HoldCaptured, captured.Hold.Status)
+}
+
+func TestCreateHoldChecksLimits(t *testing.T) {
+	sp := testStorage(t)
+	ctx := context.Background()
+	walletID := createTestWallet(t, sp, 1000)
+
+	maxWithdrawal := int64(100)
+	_, err := sp.SetWalletLimits(ctx, walletID, Limits{MaxWithdrawal: &maxWithdrawal})
+	require.NoError(t, err)
+
+	_, err = sp.CreateHold(ctx, walletID, 300, "USD", time.Hour, OperationMeta{})
+	assert.ErrorIs(t, err, storage.ErrLimitExceeded)
+}
+
+// Холд, который ExpireHolds еще не снял, после expires_at считается
+// истекшим, а не активным.
+func TestCaptureHoldPastExpiry(t *testing.T) {
+	sp := testStorage(t)
+	ctx := context.Background()
+	walletID := createTestWallet(t, sp, 1000)
+
+	res, err := sp.CreateHold(ctx, walletID, 300, "USD", time.Millisecond, OperationMeta{})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = sp.CaptureHold(ctx, walletID, res.Hold.HoldID, 0, OperationMeta{})
+	assert.ErrorIs(t, err, storage.ErrHoldExpired)
+
+	_, err = sp.VoidHold(ctx, walletID, res.Hold.HoldID, OperationMeta{})
+	assert.ErrorIs(t, err, storage.ErrHoldExpired)
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"wallet/storage"
+)
+
+// idempotencyKey — ключи разных владельцев не пересекаются, чтобы клиент не
+// мог получить сохраненный ответ по чужому ключу.
+func (meta OperationMeta) idempotencyKey() string {
+	if meta.OwnerID == "" {
+		return meta.IdempotencyKey
+	}
+	return meta.OwnerID + ":" + meta.IdempotencyKey
+}
+
+// claimIdempotencyKey резервирует ключ в текущей транзакции. Конкурентный
+// запрос с тем же ключом блокируется на вставке до коммита первого. Если ключ
+// уже использован, сохраненный ответ декодируется в dst и возвращается true.
+func claimIdempotencyKey(ctx context.Context, tx *sql.Tx, meta OperationMeta, dst any) (bool, error) {
+	if meta.IdempotencyKey == "" {
+		return false, nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO idempotency_keys (idempotency_key, fingerprint)
+		VALUES ($1, $2)
+		ON CONFLICT (idempotency_key) DO NOTHING;
+	`)
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare idempotency statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, meta.idempotencyKey(), meta.Fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if inserted == 1 {
+		return false, nil
+	}
+
+	var (
+		fingerprint string
+		response    []byte
+	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT fingerprint, response FROM idempotency_keys WHERE idempotency_key = $1",
+		meta.idempotencyKey(),
+	).Scan(&fingerprint, &response)
+	if err != nil {
+		return false, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+
+	if fingerprint != meta.Fingerprint {
+		return false, storage.ErrIdempotencyConflict
+	}
+	if response == nil {
+		return false, errors.New("idempotency key has no stored response")
+	}
+
+	if err := json.Unmarshal(response, dst); err != nil {
+		return false, fmt.Errorf("failed to decode stored response: %w", err)
+	}
+
+	return true, nil
+}
+
+func saveIdempotencyResponse(ctx context.Context, tx *sql.Tx, meta OperationMeta, v any) error {
+	if meta.IdempotencyKey == "" {
+		return nil
+	}
+
+	response, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE idempotency_keys SET response = $1 WHERE idempotency_key = $2",
+		response, meta.idempotencyKey(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"wallet/storage"
+)
+
+// Системные счета. Счет кошелька — WalletAccount(id).
+const (
+	AccountExternalBank      = "external:bank"
+	AccountFeesRevenue       = "fees:revenue"
+	AccountEquityAdjustments = "equity:adjustments"
+	AccountFXConversion      = "fx:conversion"
+
+	walletAccountPrefix = "wallet:"
+)
+
+// externalAccounts — встречный счет для движений между кошельком и внешним
+// миром. Переводы между кошельками проводятся в TransferWallet одной
+// проводкой на обе стороны.
+var externalAccounts = map[string]string{
+	OperationOpening:  AccountExternalBank,
+	OperationDeposit:  AccountExternalBank,
+	OperationWithdraw: AccountExternalBank,
+	OperationCapture:  AccountExternalBank,
+}
+
+func WalletAccount(walletID uuid.UUID) string {
+	return walletAccountPrefix + walletID.String()
+}
+
+// Posting — запись по счету. Amount со знаком: положительный увеличивает
+// остаток счета.
+type Posting struct {
+	Account  string
+	Amount   int64
+	Currency string
+}
+
+// JournalEntry — проводка. Сумма Postings по каждой валюте равна нулю.
+type JournalEntry struct {
+	Seq       int64
+	EntryID   uuid.UUID
+	Type      string
+	RequestID string
+	Postings  []Posting
+	CreatedAt time.Time
+}
+
+// AccountBalance — остаток счета в одной валюте.
+type AccountBalance struct {
+	Account  string
+	Currency string
+	Balance  int64
+}
+
+// Validate проверяет, что проводка сбалансирована: не меньше двух записей,
+// без нулевых сумм, и по каждой валюте сумма равна нулю.
+func (e JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("entry needs at least two postings: %w", storage.ErrUnbalancedEntry)
+	}
+
+	totals := map[string]int64{}
+	for _, p := range e.Postings {
+		if !validAccount(p.Account) {
+			return fmt.Errorf("invalid account %q: %w", p.Account, storage.ErrUnbalancedEntry)
+		}
+		if p.Amount == 0 {
+			return fmt.Errorf("zero posting to %s: %w", p.Account, storage.ErrUnbalancedEntry)
+		}
+		if p.Currency == "" {
+			return fmt.Errorf("posting to %s without currency: %w", p.Account, storage.ErrUnbalancedEntry)
+		}
+		totals[p.Currency] += p.Amount
+	}
+
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%s postings sum to %d: %w", currency, total, storage.ErrUnbalancedEntry)
+		}
+	}
+
+	return nil
+}
+
+// validAccount — "<тип>:<имя>" без пустых частей.
+func validAccount(account string) bool {
+	kind, name, ok := strings.Cut(account, ":")
+	return ok && kind != "" && name != ""
+}
+
+// postEntry проверяет и записывает проводку. Вызывается в транзакции
+// движения денег, которое она описывает.
+func postEntry(ctx context.Context, tx *sql.Tx, entry JournalEntry, meta OperationMeta) (err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.postEntry", &err, operationAttr(entry.Type))
+	defer end()
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	var entryID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO journal_entries (entry_type, request_id)
+		VALUES ($1, NULLIF($2, ''))
+		RETURNING entry_id;
+	`, entry.Type, meta.RequestID).Scan(&entryID); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+
+	accounts := make([]string, 0, len(entry.Postings))
+	amounts := make([]int64, 0, len(entry.Postings))
+	currencies := make([]string, 0, len(entry.Postings))
+	for _, p := range entry.Postings {
+		accounts = append(accounts, p.Account)
+		amounts = append(amounts, p.Amount)
+		currencies = append(currencies, p.Currency)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO journal_postings (entry_id, account, amount, currency)
+		SELECT $1, account, amount, currency
+		FROM unnest($2::text[], $3::bigint[], $4::text[]) AS p (account, amount, currency);
+	`, entryID, pq.Array(accounts), pq.Array(amounts), pq.Array(currencies)); err != nil {
+		return fmt.Errorf("failed to write journal postings: %w", err)
+	}
+
+	return nil
+}
+
+// postExternalEntry проводит движение между кошельком и его внешним
+// встречным счетом. Для типов операций без внешнего счета ничего не делает.
+func postExternalEntry(ctx context.Context, tx *sql.Tx, wallet Wallet, opType string, delta, fee int64, meta OperationMeta) error {
+	entry, ok := externalEntry(wallet, opType, delta, fee)
+	if !ok {
+		return nil
+	}
+	return postEntry(ctx, tx, entry, meta)
+}
+
+// externalEntry — проводка движения delta по кошельку с внешним счетом из
+// externalAccounts; комиссия fee уходит с кошелька на fees:revenue той же
+// проводкой.
+func externalEntry(wallet Wallet, opType string, delta, fee int64) (JournalEntry, bool) {
+	counter, ok := externalAccounts[opType]
+	if !ok || delta == 0 {
+		return JournalEntry{}, false
+	}
+
+	entry := JournalEntry{Type: opType}
+	if change := delta - fee; change != 0 {
+		// Пополнение, целиком ушедшее на комиссию, до кошелька не доходит.
+		entry.Postings = append(entry.Postings, Posting{Account: WalletAccount(wallet.WalletID), Amount: change, Currency: wallet.Currency})
+	}
+	entry.Postings = append(entry.Postings, Posting{Account: counter, Amount: -delta, Currency: wallet.Currency})
+
+	return withFee(entry, fee, wallet.Currency), true
+}
+
+// withFee добавляет к проводке зачисление комиссии на fees:revenue.
+// Списание комиссии с кошелька уже должно быть учтено в его записи.
+func withFee(entry JournalEntry, fee int64, currency string) JournalEntry {
+	if fee != 0 {
+		entry.Postings = append(entry.Postings, Posting{Account: AccountFeesRevenue, Amount: fee, Currency: currency})
+	}
+	return entry
+}
+
+// ListAccountBalances возвращает остатки всех счетов, у которых есть
+// записи. Пустой prefix — все счета, иначе только начинающиеся с него.
+func (sp *StoragePostgresql) ListAccountBalances(ctx context.Context, prefix string) (_ []AccountBalance, err error) {
+	const fn = "storage.postgresql.ListAccountBalances"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	rows, err := sp.db.QueryContext(ctx, `
+		SELECT account, currency, SUM(amount)
+		FROM journal_postings
+		WHERE starts_with(account, $1)
+		GROUP BY account, currency
+		ORDER BY account, currency;
+	`, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query balances: %w", fn, err)
+	}
+	defer rows.Close()
+
+	balances := []AccountBalance{}
+	for rows.Next() {
+		var b AccountBalance
+		if err := rows.Scan(&b.Account, &b.Currency, &b.Balance); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		balances = append(balances, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return balances, nil
+}
+
+// ListJournalEntries возвращает проводки от новых к старым. Непустой
+// account оставляет только проводки с записями по этому счету; beforeSeq —
+// курсор, как в OperationFilter.
+func (sp *StoragePostgresql) ListJournalEntries(ctx context.Context, account string, beforeSeq int64, limit int) (_ []JournalEntry, err error) {
+	const fn = "storage.postgresql.ListJournalEntries"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	rows, err := sp.db.QueryContext(ctx, `
+		WITH entries AS (
+			SELECT e.seq, e.entry_id, e.entry_type, COALESCE(e.request_id, ''), e.created_at
+			FROM journal_entries e
+			WHERE ($1 = '' OR EXISTS (
+					SELECT 1 FROM journal_postings p
+					WHERE p.entry_id = e.entry_id AND p.account = $1
+				))
+				AND ($2::bigint = 0 OR e.seq < $2)
+			ORDER BY e.seq DESC
+			LIMIT $3
+		)
+		SELECT e.*, p.account, p.amount, p.currency
+		FROM entries e
+		JOIN journal_postings p ON p.entry_id = e.entry_id
+		ORDER BY e.seq DESC, p.posting_id;
+	`, account, beforeSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query entries: %w", fn, err)
+	}
+	defer rows.Close()
+
+	entries := []JournalEntry{}
+	for rows.Next() {
+		var (
+			e JournalEntry
+			p Posting
+		)
+		if err := rows.Scan(&e.Seq, &e.EntryID, &e.Type, &e.RequestID, &e.CreatedAt, &p.Account, &p.Amount, &p.Currency); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		if n := len(entries); n > 0 && entries[n-1].EntryID == e.EntryID {
+			entries[n-1].Postings = append(entries[n-1].Postings, p)
+			continue
+		}
+		e.Postings = []Posting{p}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,177 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"wallet/storage"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	wallet := WalletAccount(uuid.New())
+
+	tests := []struct {
+		name     string
+		postings []Posting
+		valid    bool
+	}{
+		{
+			name: "deposit",
+			postings: []Posting{
+				{Account: AccountExternalBank, Amount: -100, Currency: "USD"},
+				{Account: wallet, Amount: 100, Currency: "USD"},
+			},
+			valid: true,
+		},
+		{
+			name: "withdrawal with fee",
+			postings: []Posting{
+				{Account: wallet, Amount: -105, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: 100, Currency: "USD"},
+				{Account: AccountFeesRevenue, Amount: 5, Currency: "USD"},
+			},
+			valid: true,
+		},
+		{
+			name: "conversion balanced per currency",
+			postings: []Posting{
+				{Account: wallet, Amount: -100, Currency: "USD"},
+				{Account: AccountFXConversion, Amount: 100, Currency: "USD"},
+				{Account: AccountFXConversion, Amount: -92, Currency: "EUR"},
+				{Account: WalletAccount(uuid.New()), Amount: 92, Currency: "EUR"},
+			},
+			valid: true,
+		},
+		{
+			name: "money out of nowhere",
+			postings: []Posting{
+				{Account: wallet, Amount: 100, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: 100, Currency: "USD"},
+			},
+		},
+		{
+			name: "sums to zero only across currencies",
+			postings: []Posting{
+				{Account: wallet, Amount: -100, Currency: "USD"},
+				{Account: WalletAccount(uuid.New()), Amount: 100, Currency: "EUR"},
+			},
+		},
+		{
+			name: "single posting",
+			postings: []Posting{
+				{Account: wallet, Amount: 0, Currency: "USD"},
+			},
+		},
+		{
+			name: "zero posting",
+			postings: []Posting{
+				{Account: wallet, Amount: 0, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: 0, Currency: "USD"},
+			},
+		},
+		{
+			name: "invalid account",
+			postings: []Posting{
+				{Account: "bank", Amount: -100, Currency: "USD"},
+				{Account: wallet, Amount: 100, Currency: "USD"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JournalEntry{Type: OperationDeposit, Postings: tt.postings}.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, storage.ErrUnbalancedEntry)
+			}
+		})
+	}
+}
+
+func TestTransferEntry(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+
+	same := transferEntry(from, to, TransferRequest{Amount: 100, Currency: "USD", CreditAmount: 100, CreditCurrency: "USD"}, 0)
+	assert.NoError(t, same.Validate())
+	assert.Len(t, same.Postings, 2)
+
+	converted := transferEntry(from, to, TransferRequest{Amount: 100, Currency: "USD", CreditAmount: 92, CreditCurrency: "EUR", Rate: "0.92"}, 0)
+	assert.NoError(t, converted.Validate())
+	assert.Len(t, converted.Postings, 4)
+
+	withFee := transferEntry(from, to, TransferRequest{Amount: 100, Currency: "USD", CreditAmount: 92, CreditCurrency: "EUR", Rate: "0.92"}, 3)
+	assert.NoError(t, withFee.Validate())
+	assert.Equal(t, Posting{Account: WalletAccount(from), Amount: -103, Currency: "USD"}, withFee.Postings[0])
+	assert.Equal(t, Posting{Account: AccountFeesRevenue, Amount: 3, Currency: "USD"}, withFee.Postings[len(withFee.Postings)-1])
+}
+
+func TestExternalEntry(t *testing.T) {
+	wallet := Wallet{WalletID: uuid.New(), Currency: "USD"}
+	account := WalletAccount(wallet.WalletID)
+
+	tests := []struct {
+		name     string
+		opType   string
+		delta    int64
+		fee      int64
+		postings []Posting
+	}{
+		{
+			name:   "deposit",
+			opType: OperationDeposit,
+			delta:  100,
+			postings: []Posting{
+				{Account: account, Amount: 100, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: -100, Currency: "USD"},
+			},
+		},
+		{
+			name:   "withdrawal with fee",
+			opType: OperationWithdraw,
+			delta:  -100,
+			fee:    5,
+			postings: []Posting{
+				{Account: account, Amount: -105, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: 100, Currency: "USD"},
+				{Account: AccountFeesRevenue, Amount: 5, Currency: "USD"},
+			},
+		},
+		{
+			name:   "deposit with fee",
+			opType: OperationDeposit,
+			delta:  100,
+			fee:    5,
+			postings: []Posting{
+				{Account: account, Amount: 95, Currency: "USD"},
+				{Account: AccountExternalBank, Amount: -100, Currency: "USD"},
+				{Account: AccountFeesRevenue, Amount: 5, Currency: "USD"},
+			},
+		},
+		{
+			name:   "deposit taken by fee",
+			opType: OperationDeposit,
+			delta:  5,
+			fee:    5,
+			postings: []Posting{
+				{Account: AccountExternalBank, Amount: -5, Currency: "USD"},
+				{Account: AccountFeesRevenue, Amount: 5, Currency: "USD"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := externalEntry(wallet, tt.opType, tt.delta, tt.fee)
+			assert.True(t, ok)
+			assert.NoError(t, entry.Validate())
+			assert.Equal(t, tt.postings, entry.Postings)
+		})
+	}
+
+	_, ok := externalEntry(wallet, OperationTransferOut, -100, 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,262 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"wallet/storage"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	LimitMaxWithdrawal     = "max_withdrawal"
+	LimitDailyWithdrawal   = "daily_withdrawal"
+	LimitMonthlyWithdrawal = "monthly_withdrawal"
+	LimitMaxBalance        = "max_balance"
+)
+
+// Limits — лимиты кошелька в минимальных единицах его валюты. nil — без
+// ограничения.
+type Limits struct {
+	MaxWithdrawal     *int64
+	DailyWithdrawal   *int64
+	MonthlyWithdrawal *int64
+	MaxBalance        *int64
+	UpdatedBy         string
+	UpdatedAt         time.Time
+}
+
+// LimitError — какой лимит сработал. Attempted — значение, которое
+// получилось бы после операции: сумма списания, итог за период или баланс.
+type LimitError struct {
+	Limit     string
+	Max       int64
+	Attempted int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %d exceeds %d: %v", e.Limit, e.Attempted, e.Max, storage.ErrLimitExceeded)
+}
+
+func (e *LimitError) Unwrap() error {
+	return storage.ErrLimitExceeded
+}
+
+// spendingOperations — списания, которые учитываются в лимитах на вывод.
+var spendingOperations = []string{OperationWithdraw, OperationTransferOut, OperationCapture}
+
+// rowQuerier — *sql.DB или *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func loadLimits(ctx context.Context, q rowQuerier, walletID uuid.UUID) (Limits, error) {
+	var (
+		limits    Limits
+		updatedBy sql.NullString
+		updatedAt sql.NullTime
+	)
+
+	err := q.QueryRowContext(ctx, `
+		SELECT max_withdrawal, daily_withdrawal, monthly_withdrawal, max_balance, updated_by, updated_at
+		FROM wallet_limits
+		WHERE wallet_id = $1;
+	`, walletID).Scan(&limits.MaxWithdrawal, &limits.DailyWithdrawal, &limits.MonthlyWithdrawal, &limits.MaxBalance, &updatedBy, &updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Limits{}, fmt.Errorf("failed to load limits: %w", err)
+	}
+
+	limits.UpdatedBy = updatedBy.String
+	limits.UpdatedAt = updatedAt.Time
+
+	return limits, nil
+}
+
+// checkLimits проверяет лимиты для операции на delta. change — на сколько
+// фактически изменится баланс с учетом комиссии: по нему проверяется
+// max_balance, а лимиты на вывод считаются по сумме операции. Вызывается под
+// блокировкой строки кошелька, поэтому параллельные списания не могут вместе
+// превысить суточный или месячный лимит.
+func checkLimits(ctx context.Context, tx *sql.Tx, wallet Wallet, opType string, delta, change int64) (err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.checkLimits", &err, walletAttr(wallet.WalletID), operationAttr(opType))
+	defer end()
+
+	limits, err := loadLimits(ctx, tx, wallet.WalletID)
+	if err != nil {
+		return err
+	}
+
+	if delta > 0 {
+		return checkMaxBalance(limits, wallet, change)
+	}
+
+	if !slices.Contains(spendingOperations, opType) {
+		return nil
+	}
+
+	amount := -delta
+	if limits.MaxWithdrawal != nil && amount > *limits.MaxWithdrawal {
+		return &LimitError{Limit: LimitMaxWithdrawal, Max: *limits.MaxWithdrawal, Attempted: amount}
+	}
+
+	if limits.DailyWithdrawal == nil && limits.MonthlyWithdrawal == nil {
+		return nil
+	}
+
+	var daily, monthly int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(-SUM(amount) FILTER (WHERE created_at >= date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'), 0)::bigint,
+			COALESCE(-SUM(amount), 0)::bigint
+		FROM wallet_operations
+		WHERE wallet_id = $1
+			AND operation_type = ANY($2)
+			AND created_at >= date_trunc('month', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+	`, wallet.WalletID, pq.Array(spendingOperations)).Scan(&daily, &monthly)
+	if err != nil {
+		return fmt.Errorf("failed to sum withdrawals: %w", err)
+	}
+
+	if limits.DailyWithdrawal != nil && daily+amount > *limits.DailyWithdrawal {
+		return &LimitError{Limit: LimitDailyWithdrawal, Max: *limits.DailyWithdrawal, Attempted: daily + amount}
+	}
+	if limits.MonthlyWithdrawal != nil && monthly+amount > *limits.MonthlyWithdrawal {
+		return &LimitError{Limit: LimitMonthlyWithdrawal, Max: *limits.MonthlyWithdrawal, Attempted: monthly + amount}
+	}
+
+	return nil
+}
+
+// checkMaxBalance проверяет, что баланс после изменения на change не
+// превысит max_balance.
+func checkMaxBalance(limits Limits, wallet Wallet, change int64) error {
+	if limits.MaxBalance != nil && wallet.Balance+change > *limits.MaxBalance {
+		return &LimitError{Limit: LimitMaxBalance, Max: *limits.MaxBalance, Attempted: wallet.Balance + change}
+	}
+	return nil
+}
+
+func (sp *StoragePostgresql) GetWalletLimits(ctx context.Context, walletID uuid.UUID) (_ Limits, err error) {
+	const fn = "storage.postgresql.GetWalletLimits"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	if _, err := sp.GetWallet(ctx, walletID); err != nil {
+		return Limits{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	limits, err := loadLimits(ctx, sp.db, walletID)
+	if err != nil {
+		return Limits{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return limits, nil
+}
+
+// SetWalletLimits заменяет все лимиты кошелька: не переданные (nil) снимаются.
+func (sp *StoragePostgresql) SetWalletLimits(ctx context.Context, walletID uuid.UUID, limits Limits) (_ Limits, err error) {
+	const fn = "storage.postgresql.SetWalletLimits"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Limits{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockWallet(ctx, tx, walletID); err != nil {
+		return Limits{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO wallet_limits (wallet_id, max_withdrawal, daily_withdrawal, monthly_withdrawal, max_balance, updated_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		ON CONFLICT (wallet_id) DO UPDATE
+		SET max_withdrawal = EXCLUDED.max_withdrawal,
+			daily_withdrawal = EXCLUDED.daily_withdrawal,
+			monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+			max_balance = EXCLUDED.max_balance,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = now();
+	`, walletID, limits.MaxWithdrawal, limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.MaxBalance, limits.UpdatedBy)
+	if err != nil {
+		return Limits{}, fmt.Errorf("%s: failed to save limits: %w", fn, err)
+	}
+
+	saved, err := loadLimits(ctx, tx, walletID)
+	if err != nil {
+		return Limits{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Limits{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return saved, nil
+}
+
+// CreditLimitChange — запись журнала изменений кредитной линии. Actor —
+// кто изменил линию (client_id администратора).
+type CreditLimitChange struct {
+	ChangeID  int64
+	WalletID  uuid.UUID
+	FromLimit int64
+	ToLimit   int64
+	Actor     string
+	RequestID string
+	CreatedAt time.Time
+}
+
+// SetCreditLimit меняет кредитную линию кошелька и записывает изменение в
+// wallet_credit_limit_changes. Уменьшение ниже текущего долга допускается:
+// кошелек остается в минусе, но новые списания отклоняются, пока доступный
+// остаток не станет положительным.
+func (sp *StoragePostgresql) SetCreditLimit(ctx context.Context, walletID uuid.UUID, creditLimit int64, change CreditLimitChange) (_ Wallet, err error) {
+	const fn = "storage.postgresql.SetCreditLimit"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	from := wallet.CreditLimit
+
+	wallet, err = scanWallet(tx.QueryRowContext(ctx,
+		"UPDATE wallets SET credit_limit = $1 WHERE wallet_id = $2 RETURNING "+walletColumns,
+		creditLimit, walletID,
+	))
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to update credit limit: %w", fn, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO wallet_credit_limit_changes (wallet_id, from_limit, to_limit, actor, request_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''));
+	`, walletID, from, creditLimit, change.Actor, change.RequestID)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to record credit limit change: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return wallet, nil
+}
@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"wallet/storage"
+)
+
+func TestCheckMaxBalance(t *testing.T) {
+	maxBalance := int64(1000)
+	limits := Limits{MaxBalance: &maxBalance}
+	wallet := Wallet{Balance: 900, Currency: "USD"}
+
+	tests := []struct {
+		name      string
+		limits    Limits
+		change    int64
+		attempted int64
+	}{
+		{name: "under cap", limits: limits, change: 100},
+		{name: "over cap", limits: limits, change: 101, attempted: 1001},
+		// Пополнение на 110 с комиссией 10 увеличит баланс только на 100.
+		{name: "fee keeps deposit under cap", limits: limits, change: 110 - 10},
+		{name: "fee deducted from attempted", limits: limits, change: 150 - 10, attempted: 1040},
+		{name: "no cap", change: 1_000_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMaxBalance(tt.limits, wallet, tt.change)
+			if tt.attempted == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, storage.ErrLimitExceeded)
+			var limitErr *LimitError
+			if assert.ErrorAs(t, err, &limitErr) {
+				assert.Equal(t, LimitError{Limit: LimitMaxBalance, Max: maxBalance, Attempted: tt.attempted}, *limitErr)
+			}
+		})
+	}
+}
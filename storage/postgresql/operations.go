@@ -0,0 +1,154 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	OperationOpening  = "OPENING"
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+
+	OperationTransferOut = "TRANSFER_OUT"
+	OperationTransferIn  = "TRANSFER_IN"
+
+	OperationCapture = "CAPTURE"
+)
+
+// Operation — запись журнала wallet_operations. Amount хранится со знаком:
+// списания отрицательные, BalanceAfter — баланс кошелька после операции.
+type Operation struct {
+	Seq          int64
+	OperationID  uuid.UUID
+	WalletID     uuid.UUID
+	Type         string
+	Amount       int64
+	BalanceAfter int64
+	Currency     string
+	Rate         string
+	RequestID    string
+	CreatedAt    time.Time
+}
+
+// OperationMeta — данные запроса, которые сохраняются вместе с операцией.
+// Если задан IdempotencyKey, повтор с тем же Fingerprint возвращает
+// сохраненный результат, а с другим — storage.ErrIdempotencyConflict.
+type OperationMeta struct {
+	RequestID      string
+	IdempotencyKey string
+	Fingerprint    string
+
+	// OwnerID ограничивает операцию кошельками этого владельца; пусто — без
+	// ограничений (аутентификация выключена или запрос от администратора).
+	OwnerID string
+
+	// rate — курс конвертации, заполняется только внутри перевода.
+	rate string
+}
+
+// OperationFilter — фильтр выборки журнала. Нулевые значения не ограничивают
+// выборку; BeforeSeq — курсор, записи возвращаются от новых к старым.
+type OperationFilter struct {
+	Types     []string
+	From      time.Time
+	To        time.Time
+	BeforeSeq int64
+	Limit     int
+
+	// OwnerID — как в OperationMeta.
+	OwnerID string
+}
+
+const operationColumns = `seq, operation_id, wallet_id, operation_type, amount, balance_after,
+	currency, COALESCE(rate::text, ''), COALESCE(request_id, ''), created_at`
+
+func scanOperation(row rowScanner) (Operation, error) {
+	var op Operation
+	err := row.Scan(
+		&op.Seq, &op.OperationID, &op.WalletID, &op.Type, &op.Amount,
+		&op.BalanceAfter, &op.Currency, &op.Rate, &op.RequestID, &op.CreatedAt,
+	)
+	return op, err
+}
+
+func recordOperation(ctx context.Context, tx *sql.Tx, wallet Wallet, opType string, amount int64, meta OperationMeta) (err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.recordOperation", &err, walletAttr(wallet.WalletID), operationAttr(opType))
+	defer end()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wallet_operations (wallet_id, operation_type, amount, balance_after, currency, rate, request_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::numeric, NULLIF($7, ''));
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare operation statement: %w", err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, wallet.WalletID, opType, amount, wallet.Balance, wallet.Currency, meta.rate, meta.RequestID); err != nil {
+		return fmt.Errorf("failed to record operation: %w", err)
+	}
+
+	return nil
+}
+
+func (sp *StoragePostgresql) ListOperations(ctx context.Context, walletID uuid.UUID, filter OperationFilter) (_ []Operation, err error) {
+	const fn = "storage.postgresql.ListOperations"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	wallet, err := sp.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := checkOwner(wallet, filter.OwnerID); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stmt, err := sp.db.PrepareContext(ctx, `
+		SELECT `+operationColumns+`
+		FROM wallet_operations
+		WHERE wallet_id = $1
+			AND ($2::bigint = 0 OR seq < $2)
+			AND (COALESCE(cardinality($3::text[]), 0) = 0 OR operation_type = ANY($3))
+			AND ($4::timestamptz IS NULL OR created_at >= $4)
+			AND ($5::timestamptz IS NULL OR created_at < $5)
+		ORDER BY seq DESC
+		LIMIT $6;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", fn, err)
+	}
+
+	rows, err := stmt.QueryContext(ctx,
+		walletID,
+		filter.BeforeSeq,
+		pq.Array(filter.Types),
+		sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+	defer rows.Close()
+
+	var operations []Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		operations = append(operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return operations, nil
+}
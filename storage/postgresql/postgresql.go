@@ -1,26 +1,62 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 	"wallet/storage"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"github.com/lib/pq"
 )
 
 type StoragePostgresql struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts Timeouts
+	fees     FeeSchedule
 }
 
+// Wallet — кошелек. Balance хранится в минимальных единицах валюты Currency
+// (ISO 4217), например в центах для USD. Held — сумма активных холдов:
+// она остается в балансе, но недоступна для списаний. CreditLimit —
+// кредитная линия: баланс может уйти в минус не больше чем на нее. Tier —
+// тариф, по которому считаются комиссии.
 type Wallet struct {
-	WalletID uuid.UUID
-	Balance int
+	WalletID    uuid.UUID
+	Balance     int64
+	Held        int64
+	CreditLimit int64
+	Currency    string
+	OwnerID     string
+	Status      string
+	Tier        string
+	CreatedAt   time.Time
+}
 
+// Available — сколько можно списать: баланс за вычетом холдов плюс
+// кредитная линия.
+func (w Wallet) Available() int64 {
+	return w.Balance - w.Held + w.CreditLimit
 }
 
-func NewStorage(dbURL string) (*StoragePostgresql, error) {
+const pgUniqueViolation = "23505"
+
+const walletColumns = "wallet_id, balance, held, credit_limit, currency, COALESCE(owner_id, ''), status, tier, created_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWallet(row rowScanner) (Wallet, error) {
+	var wallet Wallet
+	err := row.Scan(&wallet.WalletID, &wallet.Balance, &wallet.Held, &wallet.CreditLimit, &wallet.Currency, &wallet.OwnerID, &wallet.Status, &wallet.Tier, &wallet.CreatedAt)
+	return wallet, err
+}
+
+func NewStorage(dbURL string, timeouts Timeouts) (*StoragePostgresql, error) {
 	const fn = "storage.postgresql.NewStorage"
 
 	db, err := sql.Open("postgres", dbURL)
@@ -34,20 +70,31 @@ func NewStorage(dbURL string) (*StoragePostgresql, error) {
 	}
 
 
-	return &StoragePostgresql{db: db}, nil
+	return &StoragePostgresql{db: db, timeouts: timeouts}, nil
 }	
 
-func (sp *StoragePostgresql) GetWallet(wallet_uuid uuid.UUID) (Wallet, error) {
+// Close закрывает пул соединений. Вызывается после остановки HTTP-сервера.
+func (sp *StoragePostgresql) Close() error {
+	return sp.db.Close()
+}
+
+// Stats — статистика пула соединений для метрик.
+func (sp *StoragePostgresql) Stats() sql.DBStats {
+	return sp.db.Stats()
+}
+
+func (sp *StoragePostgresql) GetWallet(ctx context.Context, wallet_uuid uuid.UUID) (_ Wallet, err error) {
 	const fn = "storage.postgresql.GetWallet"
 
-	stmt, err := sp.db.Prepare("SELECT wallet_id, balance FROM wallets WHERE wallet_id = $1")
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(wallet_uuid))
+	defer done()
+
+	stmt, err := sp.db.PrepareContext(ctx, "SELECT " + walletColumns + " FROM wallets WHERE wallet_id = $1")
 	if err != nil {
 		return Wallet{}, fmt.Errorf("%s: prepare statement: %w", fn, err)
 	}
 
-	var wallet Wallet
-
-	err = stmt.QueryRow(wallet_uuid).Scan(&wallet.WalletID, &wallet.Balance)
+	wallet, err := scanWallet(stmt.QueryRowContext(ctx, wallet_uuid))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Wallet{}, storage.ErrWalletNotFound
@@ -58,101 +105,244 @@ func (sp *StoragePostgresql) GetWallet(wallet_uuid uuid.UUID) (Wallet, error) {
 	return wallet, nil
 }
 
+// CreateWallet создает кошелек. Если wallet.WalletID равен uuid.Nil,
+// идентификатор генерирует база (gen_random_uuid()). Ненулевой начальный
+// баланс записывается в журнал операцией OPENING и, как любое изменение
+// баланса, событием в outbox.
+func (sp *StoragePostgresql) CreateWallet(ctx context.Context, wallet Wallet, meta OperationMeta) (_ Wallet, err error) {
+	const fn = "storage.postgresql.CreateWallet"
 
-func (sp *StoragePostgresql) DepositWallet(walletID uuid.UUID, amount int64) (Wallet, error) {
-	const fn = "storage.postgresql.DepositWallet"
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
 
-	tx, err := sp.db.Begin()
+	tx, err := sp.db.BeginTx(ctx, nil)
 	if err != nil {
 		return Wallet{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
 	}
+	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`
-		UPDATE wallets 
-		SET balance = balance + $1 
-		WHERE wallet_id = $2
-		RETURNING wallet_id, balance;
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wallets (wallet_id, balance, currency, owner_id)
+		VALUES (COALESCE($1, gen_random_uuid()), $2, $3, NULLIF($4, ''))
+		RETURNING ` + walletColumns + `;
 	`)
 	if err != nil {
-		tx.Rollback()
-		return Wallet{}, fmt.Errorf("%s: failed to prepare statement: %w", fn, err)
+		return Wallet{}, fmt.Errorf("%s: prepare statement: %w", fn, err)
 	}
 
-	var wallet Wallet
-	err = stmt.QueryRow(amount, walletID).Scan(&wallet.WalletID, &wallet.Balance)
+	id := uuid.NullUUID{UUID: wallet.WalletID, Valid: wallet.WalletID != uuid.Nil}
+
+	created, err := scanWallet(stmt.QueryRowContext(ctx, id, wallet.Balance, wallet.Currency, wallet.OwnerID))
 	if err != nil {
-		tx.Rollback()
-		if err == sql.ErrNoRows {
-			return Wallet{}, fmt.Errorf("%s: wallet not found: %w", fn, storage.ErrWalletNotFound)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+			return Wallet{}, fmt.Errorf("%s: %w", fn, storage.ErrWalletExists)
+		}
+		return Wallet{}, fmt.Errorf("%s: execute statement: %w", fn, err)
+	}
+
+	if created.Balance != 0 {
+		if err := recordOperation(ctx, tx, created, OperationOpening, created.Balance, meta); err != nil {
+			return Wallet{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		if err := postExternalEntry(ctx, tx, created, OperationOpening, created.Balance, 0, meta); err != nil {
+			return Wallet{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		if err := writeEvent(ctx, tx, created, OperationOpening, created.Balance, 0, meta); err != nil {
+			return Wallet{}, fmt.Errorf("%s: %w", fn, err)
 		}
-		return Wallet{}, fmt.Errorf("%s: failed to execute statement: %w", fn, err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return Wallet{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
 	}
 
-	return wallet, nil
+	return created, nil
 }
 
+func (sp *StoragePostgresql) DepositWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta OperationMeta) (OperationResult, error) {
+	const fn = "storage.postgresql.DepositWallet"
 
+	return sp.changeBalance(ctx, fn, walletID, OperationDeposit, amount, currency, meta)
+}
 
-func (sp *StoragePostgresql) WithdrawWallet(walletID uuid.UUID, amount int64) (Wallet, error) {
+func (sp *StoragePostgresql) WithdrawWallet(ctx context.Context, walletID uuid.UUID, amount int64, currency string, meta OperationMeta) (OperationResult, error) {
 	const fn = "storage.postgresql.WithdrawWallet"
 
-	tx, err := sp.db.Begin()
+	return sp.changeBalance(ctx, fn, walletID, OperationWithdraw, -amount, currency, meta)
+}
+
+func (sp *StoragePostgresql) changeBalance(ctx context.Context, fn string, walletID uuid.UUID, opType string, delta int64, currency string, meta OperationMeta) (_ OperationResult, err error) {
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID), operationAttr(opType))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
 	if err != nil {
-		return Wallet{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+		return OperationResult{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
 	}
+	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`
-		UPDATE wallets 
-		SET balance = balance - $1 
-		WHERE wallet_id = $2 AND balance >= $3
-		RETURNING wallet_id, balance;
-	`)
+	var result OperationResult
+
+	replayed, err := claimIdempotencyKey(ctx, tx, meta, &result)
 	if err != nil {
-		tx.Rollback()
-		return Wallet{}, fmt.Errorf("%s: failed to prepare statement: %w", fn, err)
+		return OperationResult{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if replayed {
+		result.Replayed = true
+		return result, nil
 	}
 
-	ok, err := sp.IsExistsWallet(walletID)
+	result.Wallet, result.Fee, err = applyBalanceChange(ctx, tx, walletID, opType, delta, currency, sp.fees, meta)
 	if err != nil {
-		return Wallet{}, err
+		return OperationResult{}, fmt.Errorf("%s: %w", fn, err)
 	}
-	
-	if !ok {
-		return Wallet{}, storage.ErrWalletNotFound
+
+	if err := saveIdempotencyResponse(ctx, tx, meta, result); err != nil {
+		return OperationResult{}, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	var wallet Wallet
-	err = stmt.QueryRow(amount, walletID, amount).Scan(&wallet.WalletID, &wallet.Balance)
+	if err := tx.Commit(); err != nil {
+		return OperationResult{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return result, nil
+}
+
+// lockWallet блокирует строку кошелька до конца транзакции.
+func lockWallet(ctx context.Context, tx *sql.Tx, walletID uuid.UUID) (_ Wallet, err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.lockWallet", &err, walletAttr(walletID))
+	defer end()
+
+	stmt, err := tx.PrepareContext(ctx, "SELECT " + walletColumns + " FROM wallets WHERE wallet_id = $1 FOR UPDATE")
 	if err != nil {
-		tx.Rollback()
-		if err == sql.ErrNoRows {
-			return Wallet{}, fmt.Errorf("%s: insufficient funds: %w", fn, storage.ErrInsufficientFunds)
-		}
-		return Wallet{}, fmt.Errorf("%s: failed to execute statement: %w", fn, err)
+		return Wallet{}, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return Wallet{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	wallet, err := scanWallet(stmt.QueryRowContext(ctx, walletID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Wallet{}, fmt.Errorf("wallet not found: %w", storage.ErrWalletNotFound)
+		}
+		return Wallet{}, fmt.Errorf("failed to lock wallet: %w", err)
 	}
 
 	return wallet, nil
 }
 
+// checkOwner проверяет, что кошелек принадлежит ownerID. Пустой ownerID
+// означает отсутствие ограничений.
+func checkOwner(wallet Wallet, ownerID string) error {
+	if ownerID != "" && wallet.OwnerID != ownerID {
+		return fmt.Errorf("wallet belongs to another owner: %w", storage.ErrForbidden)
+	}
+	return nil
+}
+
+// applyBalanceChange — общая часть всех движений по кошельку: блокирует
+// строку, проверяет статус, валюту, доступный остаток и лимиты, меняет
+// баланс на delta за вычетом комиссии по fees (nil — без комиссии), пишет
+// операцию и комиссию в журнал, проводку по внешнему счету (для операций из
+// externalAccounts) и событие в outbox. Вызывается внутри транзакции.
+// Возвращает кошелек и взятую комиссию.
+func applyBalanceChange(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, opType string, delta int64, currency string, fees FeeSchedule, meta OperationMeta) (_ Wallet, fee int64, err error) {
+	ctx, end := startSpan(ctx, "storage.postgresql.applyBalanceChange", &err,
+		walletAttr(walletID),
+		operationAttr(opType),
+		attribute.Int64("wallet.amount", delta),
+		attribute.String("request_id", meta.RequestID),
+	)
+	defer end()
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return Wallet{}, 0, err
+	}
+
+	if err := checkOwner(wallet, meta.OwnerID); err != nil {
+		return Wallet{}, 0, err
+	}
+
+	if err := checkStatus(wallet); err != nil {
+		return Wallet{}, 0, err
+	}
+
+	if wallet.Currency != currency {
+		return Wallet{}, 0, fmt.Errorf("wallet currency is %s: %w", wallet.Currency, storage.ErrCurrencyMismatch)
+	}
+
+	fee, err = operationFee(fees, wallet, opType, delta)
+	if err != nil {
+		return Wallet{}, 0, err
+	}
+
+	// Комиссия списывается вместе с операцией и тоже должна поместиться в
+	// доступный остаток. Лимиты на вывод считаются по сумме операции без
+	// комиссии, max_balance — по балансу, который будет записан.
+	change := delta - fee
+	if change < 0 && wallet.Available()+change < 0 {
+		return Wallet{}, 0, fmt.Errorf("insufficient funds: %w", storage.ErrInsufficientFunds)
+	}
+
+	if err := checkLimits(ctx, tx, wallet, opType, delta, change); err != nil {
+		return Wallet{}, 0, err
+	}
+
+	wallet, err = writeBalanceChange(ctx, tx, walletID, opType, delta, fee, meta)
+	if err != nil {
+		return Wallet{}, 0, err
+	}
+
+	return wallet, fee, nil
+}
+
+// writeBalanceChange меняет баланс на delta за вычетом fee и пишет операцию,
+// проводку и событие — без проверок: их делает вызывающий под блокировкой
+// кошелька.
+func writeBalanceChange(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, opType string, delta, fee int64, meta OperationMeta) (Wallet, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE wallets 
+		SET balance = balance + $1 
+		WHERE wallet_id = $2
+		RETURNING ` + walletColumns + `;
+	`)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	wallet, err := scanWallet(stmt.QueryRowContext(ctx, delta-fee, walletID))
+	if err != nil {
+		return Wallet{}, fmt.Errorf("failed to execute statement: %w", err)
+	}
 
-func (sp *StoragePostgresql) IsExistsWallet(walletID uuid.UUID) (bool, error) {
+	if err := recordOperationWithFee(ctx, tx, wallet, opType, delta, fee, meta); err != nil {
+		return Wallet{}, err
+	}
+
+	if err := postExternalEntry(ctx, tx, wallet, opType, delta, fee, meta); err != nil {
+		return Wallet{}, err
+	}
+
+	if err := writeEvent(ctx, tx, wallet, opType, delta, fee, meta); err != nil {
+		return Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+func (sp *StoragePostgresql) IsExistsWallet(ctx context.Context, walletID uuid.UUID) (_ bool, err error) {
 	const fn = "storage.postgresql.IsExistsWallet"
 
-	stmt, err := sp.db.Prepare("SELECT EXISTS(SELECT 1 FROM wallets WHERE wallet_id = $1)")
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	stmt, err := sp.db.PrepareContext(ctx, "SELECT EXISTS(SELECT 1 FROM wallets WHERE wallet_id = $1)")
 	if err != nil {
 		return false, fmt.Errorf("%s: prepare statement: %w", fn, err)
 	}
 	var exists bool
 	
-	err = stmt.QueryRow(walletID).Scan(&exists)
+	err = stmt.QueryRowContext(ctx, walletID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("%s: failed to check wallet existence: %w", fn, storage.ErrWalletNotFound)
 	}
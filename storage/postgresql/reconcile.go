@@ -0,0 +1,151 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// OperationAdjustment — компенсирующая запись сверки: приводит сумму
+// журнала к wallets.balance, сам баланс не меняет.
+const OperationAdjustment = "ADJUSTMENT"
+
+// Mismatch — расхождение баланса кошелька с журналом операций или с его
+// счетом в двойной записи. Computed — сумма операций, Ledger — остаток
+// счета кошелька; Difference = Balance - Computed, LedgerDifference =
+// Balance - Ledger.
+type Mismatch struct {
+	WalletID         uuid.UUID
+	Currency         string
+	Balance          int64
+	Computed         int64
+	Difference       int64
+	Ledger           int64
+	LedgerDifference int64
+}
+
+// FindBalanceMismatches сравнивает wallets.balance с суммой операций и с
+// остатком счета кошелька в двойной записи по всем кошелькам и возвращает
+// расхождения. Возвращает также число проверенных кошельков.
+func (sp *StoragePostgresql) FindBalanceMismatches(ctx context.Context) (_ []Mismatch, checked int, err error) {
+	const fn = "storage.postgresql.FindBalanceMismatches"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	// Один запрос — один снимок: баланс и операция пишутся в одной
+	// транзакции, поэтому параллельные операции не дают ложных расхождений.
+	rows, err := sp.db.QueryContext(ctx, `
+		SELECT w.wallet_id, w.currency, COALESCE(w.balance, 0), COALESCE(o.total, 0),
+			COALESCE(j.total, 0), count(*) OVER ()
+		FROM wallets w
+		LEFT JOIN (
+			SELECT wallet_id, SUM(amount) AS total
+			FROM wallet_operations
+			GROUP BY wallet_id
+		) o ON o.wallet_id = w.wallet_id
+		LEFT JOIN (
+			SELECT account, currency, SUM(amount)::bigint AS total
+			FROM journal_postings
+			WHERE account LIKE $1::text || '%'
+			GROUP BY account, currency
+		) j ON j.account = $1::text || w.wallet_id::text AND j.currency = w.currency
+		ORDER BY (COALESCE(w.balance, 0) = COALESCE(o.total, 0) AND COALESCE(w.balance, 0) = COALESCE(j.total, 0)),
+			w.wallet_id;
+	`, walletAccountPrefix)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: failed to query balances: %w", fn, err)
+	}
+	defer rows.Close()
+
+	mismatches := []Mismatch{}
+	for rows.Next() {
+		var m Mismatch
+		if err := rows.Scan(&m.WalletID, &m.Currency, &m.Balance, &m.Computed, &m.Ledger, &checked); err != nil {
+			return nil, 0, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		if m.Balance == m.Computed && m.Balance == m.Ledger {
+			// Расхождения отсортированы первыми, дальше только совпадения.
+			break
+		}
+		m.Difference = m.Balance - m.Computed
+		m.LedgerDifference = m.Balance - m.Ledger
+		mismatches = append(mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return mismatches, checked, nil
+}
+
+// FixBalanceMismatch пересчитывает расхождения кошелька под блокировкой:
+// разницу с журналом операций дописывает операцией ADJUSTMENT, разницу со
+// счетом кошелька в двойной записи списывает на equity:adjustments.
+// Возвращает записанные расхождения; нулевые Difference и LedgerDifference —
+// исправлять нечего.
+func (sp *StoragePostgresql) FixBalanceMismatch(ctx context.Context, walletID uuid.UUID, meta OperationMeta) (_ Mismatch, err error) {
+	const fn = "storage.postgresql.FixBalanceMismatch"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Mismatch{}, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return Mismatch{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	m := Mismatch{WalletID: walletID, Currency: wallet.Currency, Balance: wallet.Balance}
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM wallet_operations WHERE wallet_id = $1", walletID,
+	).Scan(&m.Computed); err != nil {
+		return Mismatch{}, fmt.Errorf("%s: failed to sum operations: %w", fn, err)
+	}
+
+	// Счет кошелька в двойной записи расходится с балансом не обязательно
+	// на ту же сумму, что и журнал операций: проводки начались с остатков на
+	// момент миграции.
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM journal_postings WHERE account = $1 AND currency = $2",
+		WalletAccount(walletID), wallet.Currency,
+	).Scan(&m.Ledger); err != nil {
+		return Mismatch{}, fmt.Errorf("%s: failed to sum postings: %w", fn, err)
+	}
+
+	m.Difference = m.Balance - m.Computed
+	m.LedgerDifference = m.Balance - m.Ledger
+	if m.Difference == 0 && m.LedgerDifference == 0 {
+		return m, nil
+	}
+
+	if m.Difference != 0 {
+		if err := recordOperation(ctx, tx, wallet, OperationAdjustment, m.Difference, meta); err != nil {
+			return Mismatch{}, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if m.LedgerDifference != 0 {
+		if err := postEntry(ctx, tx, JournalEntry{
+			Type: OperationAdjustment,
+			Postings: []Posting{
+				{Account: WalletAccount(walletID), Amount: m.LedgerDifference, Currency: wallet.Currency},
+				{Account: AccountEquityAdjustments, Amount: -m.LedgerDifference, Currency: wallet.Currency},
+			},
+		}, meta); err != nil {
+			return Mismatch{}, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Mismatch{}, fmt.Errorf("%s: commit transaction: %w", fn, err)
+	}
+
+	return m, nil
+}
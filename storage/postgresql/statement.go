@@ -0,0 +1,171 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wallet/storage"
+)
+
+// BalanceAt — баланс кошелька на момент At, восстановленный по журналу
+// операций. LastOperation — последняя операция не позже At; nil, если
+// операций еще не было и баланс нулевой.
+type BalanceAt struct {
+	WalletID      uuid.UUID
+	Balance       int64
+	Currency      string
+	At            time.Time
+	LastOperation *Operation
+}
+
+// Statement — выписка за период [From, To): баланс на начало, операции
+// периода в порядке выполнения и баланс на конец.
+type Statement struct {
+	WalletID       uuid.UUID
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	Operations     []Operation
+}
+
+// GetBalanceAt возвращает баланс кошелька на момент at. ownerID — как в
+// OperationMeta.
+func (sp *StoragePostgresql) GetBalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time, ownerID string) (_ BalanceAt, err error) {
+	const fn = "storage.postgresql.GetBalanceAt"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	wallet, err := sp.GetWallet(ctx, walletID)
+	if err != nil {
+		return BalanceAt{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := checkOwner(wallet, ownerID); err != nil {
+		return BalanceAt{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res := BalanceAt{WalletID: walletID, Currency: wallet.Currency, At: at}
+
+	op, err := lastOperationBefore(ctx, sp.db, walletID, at, true)
+	if err != nil {
+		return BalanceAt{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if op != nil {
+		res.Balance = op.BalanceAfter
+		res.LastOperation = op
+	}
+
+	return res, nil
+}
+
+// GetStatement собирает выписку за [from, to). Если операций в периоде
+// больше maxOperations, возвращает storage.ErrStatementTooLarge.
+func (sp *StoragePostgresql) GetStatement(ctx context.Context, walletID uuid.UUID, from, to time.Time, ownerID string, maxOperations int) (_ Statement, err error) {
+	const fn = "storage.postgresql.GetStatement"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	// Один снимок базы на все запросы: операции, записанные во время
+	// построения выписки, не разъедутся с балансом на начало.
+	tx, err := sp.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return Statement{}, fmt.Errorf("%s: begin transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	wallet, err := scanWallet(tx.QueryRowContext(ctx, "SELECT "+walletColumns+" FROM wallets WHERE wallet_id = $1", walletID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Statement{}, fmt.Errorf("%s: %w", fn, storage.ErrWalletNotFound)
+		}
+		return Statement{}, fmt.Errorf("%s: failed to get wallet: %w", fn, err)
+	}
+	if err := checkOwner(wallet, ownerID); err != nil {
+		return Statement{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	statement := Statement{
+		WalletID:   walletID,
+		Currency:   wallet.Currency,
+		From:       from,
+		To:         to,
+		Operations: []Operation{},
+	}
+
+	opening, err := lastOperationBefore(ctx, tx, walletID, from, false)
+	if err != nil {
+		return Statement{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if opening != nil {
+		statement.OpeningBalance = opening.BalanceAfter
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+operationColumns+`
+		FROM wallet_operations
+		WHERE wallet_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, seq
+		LIMIT $4;
+	`, walletID, from, to, maxOperations+1)
+	if err != nil {
+		return Statement{}, fmt.Errorf("%s: failed to query operations: %w", fn, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return Statement{}, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		statement.Operations = append(statement.Operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		return Statement{}, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	if len(statement.Operations) > maxOperations {
+		return Statement{}, fmt.Errorf("%s: %w", fn, storage.ErrStatementTooLarge)
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	if n := len(statement.Operations); n > 0 {
+		statement.ClosingBalance = statement.Operations[n-1].BalanceAfter
+	}
+
+	return statement, nil
+}
+
+// lastOperationBefore возвращает последнюю операцию кошелька раньше at
+// (inclusive — не позже at) или nil, если таких нет. Порядок — тот же, по
+// которому отбираются операции: по created_at, а seq лишь уточняет его для
+// одинакового времени.
+func lastOperationBefore(ctx context.Context, q rowQuerier, walletID uuid.UUID, at time.Time, inclusive bool) (*Operation, error) {
+	cond := "created_at < $2"
+	if inclusive {
+		cond = "created_at <= $2"
+	}
+
+	op, err := scanOperation(q.QueryRowContext(ctx, `
+		SELECT `+operationColumns+`
+		FROM wallet_operations
+		WHERE wallet_id = $1 AND `+cond+`
+		ORDER BY created_at DESC, seq DESC
+		LIMIT 1;
+	`, walletID, at))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last operation: %w", err)
+	}
+
+	return &op, nil
+}
@@ -0,0 +1,73 @@
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorage подключается к базе из TEST_DATABASE_URL с примененными
+// миграциями. Без переменной тест пропускается.
+func testStorage(t *testing.T) *StoragePostgresql {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	sp, err := NewStorage(dbURL, Timeouts{})
+	require.NoError(t, err)
+	t.Cleanup(func() { sp.Close() })
+
+	return sp
+}
+
+// Операция с меньшим seq может получить более позднее created_at, если ее
+// транзакция началась раньше. Баланс на дату выбирается по created_at.
+func TestBalanceAtOrdersByCreatedAt(t *testing.T) {
+	sp := testStorage(t)
+	ctx := context.Background()
+
+	var walletID uuid.UUID
+	require.NoError(t, sp.db.QueryRowContext(ctx,
+		"INSERT INTO wallets (currency, balance) VALUES ('USD', 300) RETURNING wallet_id",
+	).Scan(&walletID))
+
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	insert := func(amount, balanceAfter int64, at time.Time) {
+		_, err := sp.db.ExecContext(ctx, `
+			INSERT INTO wallet_operations (wallet_id, operation_type, amount, balance_after, currency, created_at)
+			VALUES ($1, $2, $3, $4, 'USD', $5);
+		`, walletID, OperationDeposit, amount, balanceAfter, at)
+		require.NoError(t, err)
+	}
+
+	// Первая по seq, но позже по времени.
+	insert(100, 300, base.Add(2*time.Second))
+	insert(200, 200, base.Add(time.Second))
+
+	res, err := sp.GetBalanceAt(ctx, walletID, base.Add(3*time.Second), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), res.Balance)
+
+	res, err = sp.GetBalanceAt(ctx, walletID, base.Add(1500*time.Millisecond), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), res.Balance)
+
+	statement, err := sp.GetStatement(ctx, walletID, base, base.Add(time.Minute), "", 10)
+	require.NoError(t, err)
+	require.Len(t, statement.Operations, 2)
+	assert.Equal(t, int64(200), statement.Operations[0].Amount)
+	assert.Equal(t, int64(300), statement.ClosingBalance)
+
+	statement, err = sp.GetStatement(ctx, walletID, base.Add(1500*time.Millisecond), base.Add(time.Minute), "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), statement.OpeningBalance)
+	assert.Equal(t, int64(300), statement.ClosingBalance)
+}
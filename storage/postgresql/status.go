@@ -0,0 +1,143 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+	"wallet/storage"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	WalletActive = "ACTIVE"
+	WalletFrozen = "FROZEN"
+	WalletClosed = "CLOSED"
+)
+
+// statusTransitions — допустимые переходы статуса. CLOSED — конечное
+// состояние, из него перейти нельзя.
+var statusTransitions = map[string][]string{
+	WalletActive: {WalletFrozen, WalletClosed},
+	WalletFrozen: {WalletActive, WalletClosed},
+}
+
+// StatusChange — запись журнала смены статуса. Actor — кто сменил статус
+// (client_id администратора), Reason — обязательное обоснование.
+type StatusChange struct {
+	ChangeID   int64
+	WalletID   uuid.UUID
+	FromStatus string
+	ToStatus   string
+	Actor      string
+	Reason     string
+	RequestID  string
+	CreatedAt  time.Time
+}
+
+func IsValidStatus(status string) bool {
+	return status == WalletActive || status == WalletFrozen || status == WalletClosed
+}
+
+// checkStatus запрещает движения средств по замороженным и закрытым кошелькам.
+func checkStatus(wallet Wallet) error {
+	switch wallet.Status {
+	case WalletFrozen:
+		return fmt.Errorf("wallet %s: %w", wallet.WalletID, storage.ErrWalletFrozen)
+	case WalletClosed:
+		return fmt.Errorf("wallet %s: %w", wallet.WalletID, storage.ErrWalletClosed)
+	}
+	return nil
+}
+
+// ChangeWalletStatus переводит кошелек в status и записывает переход в
+// wallet_status_changes. Закрыть можно только кошелек с нулевым балансом и
+// без активных холдов.
+func (sp *StoragePostgresql) ChangeWalletStatus(ctx context.Context, walletID uuid.UUID, status string, change StatusChange) (_ Wallet, err error) {
+	const fn = "storage.postgresql.ChangeWalletStatus"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, walletAttr(walletID), attribute.String("wallet.status", status))
+	defer done()
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	wallet, err := lockWallet(ctx, tx, walletID)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if !slices.Contains(statusTransitions[wallet.Status], status) {
+		return Wallet{}, fmt.Errorf("%s: %s -> %s: %w", fn, wallet.Status, status, storage.ErrInvalidStatusTransition)
+	}
+
+	if status == WalletClosed && (wallet.Balance != 0 || wallet.Held != 0) {
+		return Wallet{}, fmt.Errorf("%s: balance %d, held %d: %w", fn, wallet.Balance, wallet.Held, storage.ErrWalletNotEmpty)
+	}
+
+	from := wallet.Status
+
+	wallet, err = scanWallet(tx.QueryRowContext(ctx,
+		"UPDATE wallets SET status = $1 WHERE wallet_id = $2 RETURNING "+walletColumns,
+		status, walletID,
+	))
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to update status: %w", fn, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO wallet_status_changes (wallet_id, from_status, to_status, actor, reason, request_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''));
+	`, walletID, from, status, change.Actor, change.Reason, change.RequestID)
+	if err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to record status change: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Wallet{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return wallet, nil
+}
+
+// ListStatusChanges возвращает историю статусов кошелька, от старых к новым.
+func (sp *StoragePostgresql) ListStatusChanges(ctx context.Context, walletID uuid.UUID) (_ []StatusChange, err error) {
+	const fn = "storage.postgresql.ListStatusChanges"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, walletAttr(walletID))
+	defer done()
+
+	if _, err := sp.GetWallet(ctx, walletID); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := sp.db.QueryContext(ctx, `
+		SELECT change_id, wallet_id, from_status, to_status, actor, reason, COALESCE(request_id, ''), created_at
+		FROM wallet_status_changes
+		WHERE wallet_id = $1
+		ORDER BY change_id;
+	`, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query status changes: %w", fn, err)
+	}
+	defer rows.Close()
+
+	changes := []StatusChange{}
+	for rows.Next() {
+		var c StatusChange
+		if err := rows.Scan(&c.ChangeID, &c.WalletID, &c.FromStatus, &c.ToStatus, &c.Actor, &c.Reason, &c.RequestID, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan status change: %w", fn, err)
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wallet/storage"
+
+	"github.com/lib/pq"
+)
+
+// Timeouts — дедлайны запросов к БД. Read — для выборок, Write — для
+// транзакций, меняющих данные. Нулевое значение не ограничивает запрос.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// pgQueryCanceled — запрос отменен сервером (в т.ч. по отмене контекста).
+const pgQueryCanceled = "57014"
+
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	canceled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &pqErr) && pqErr.Code == pgQueryCanceled)
+	if !canceled {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", storage.ErrTimeout, err)
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%w: %w", storage.ErrCanceled, err)
+	default:
+		// Запрос отменил кто-то на стороне БД (statement_timeout и т.п.).
+		return fmt.Errorf("%w: %w", storage.ErrTimeout, err)
+	}
+}
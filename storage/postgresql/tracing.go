@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("wallet/storage/postgresql")
+
+var dbSystem = attribute.String("db.system", "postgresql")
+
+func walletAttr(walletID uuid.UUID) attribute.KeyValue {
+	return attribute.String("wallet.id", walletID.String())
+}
+
+func operationAttr(opType string) attribute.KeyValue {
+	return attribute.String("wallet.operation_type", opType)
+}
+
+// startOp открывает span операции storage и ограничивает ctx таймаутом.
+// Возвращаемая функция переводит ошибки отмены в storage.ErrCanceled или
+// storage.ErrTimeout, записывает ошибку в span, закрывает его и снимает
+// дедлайн.
+func startOp(ctx context.Context, name string, timeout time.Duration, errp *error, attrs ...attribute.KeyValue) (context.Context, func()) {
+	cancel := func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	ctx, end := startSpan(ctx, name, errp, attrs...)
+
+	return ctx, func() {
+		*errp = contextError(ctx, *errp)
+		end()
+		cancel()
+	}
+}
+
+// startSpan открывает дочерний span для шага внутри транзакции.
+func startSpan(ctx context.Context, name string, errp *error, attrs ...attribute.KeyValue) (context.Context, func()) {
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, dbSystem)...),
+	)
+
+	return ctx, func() {
+		if err := *errp; err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
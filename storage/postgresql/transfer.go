@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TransferRequest — перевод Amount в валюте Currency с FromID на ToID.
+// Для переводов между валютами получатель зачисляет CreditAmount в
+// CreditCurrency по курсу Rate; для одной валюты эти поля можно не заполнять.
+type TransferRequest struct {
+	FromID   uuid.UUID
+	ToID     uuid.UUID
+	Amount   int64
+	Currency string
+
+	CreditAmount   int64
+	CreditCurrency string
+	Rate           string
+}
+
+// Transfer — результат перевода. Fee — комиссия, списанная с FromID
+// сверх Amount.
+type Transfer struct {
+	From     Wallet
+	To       Wallet
+	Amount   int64
+	Fee      int64
+	Currency string
+
+	CreditAmount   int64
+	CreditCurrency string
+	Rate           string
+}
+
+// TransferWallet списывает сумму с FromID и зачисляет на ToID в одной
+// транзакции. Строки блокируются в порядке wallet_id, поэтому встречные
+// переводы между одной парой кошельков не приводят к взаимной блокировке.
+// Валюты кошельков проверяются под блокировкой.
+func (sp *StoragePostgresql) TransferWallet(ctx context.Context, req TransferRequest, meta OperationMeta) (_ Transfer, err error) {
+	const fn = "storage.postgresql.TransferWallet"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, attribute.String("wallet.from_id", req.FromID.String()), attribute.String("wallet.to_id", req.ToID.String()))
+	defer done()
+
+	fromID, toID := req.FromID, req.ToID
+
+	if req.CreditCurrency == "" {
+		req.CreditCurrency = req.Currency
+		req.CreditAmount = req.Amount
+	}
+
+	if fromID == toID {
+		return Transfer{}, fmt.Errorf("%s: %w", fn, errors.New("source and destination wallets are the same"))
+	}
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("%s: failed to start transaction: %w", fn, err)
+	}
+	defer tx.Rollback()
+
+	var transfer Transfer
+
+	replayed, err := claimIdempotencyKey(ctx, tx, meta, &transfer)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if replayed {
+		return transfer, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		SELECT 1
+		FROM wallets
+		WHERE wallet_id = ANY($1::uuid[])
+		ORDER BY wallet_id
+		FOR UPDATE;
+	`, pq.Array([]string{fromID.String(), toID.String()}))
+	if err != nil {
+		return Transfer{}, fmt.Errorf("%s: failed to lock wallets: %w", fn, err)
+	}
+
+	legMeta := meta
+	legMeta.rate = req.Rate
+
+	transfer.From, transfer.Fee, err = applyBalanceChange(ctx, tx, fromID, OperationTransferOut, -req.Amount, req.Currency, sp.fees, legMeta)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("%s: source wallet: %w", fn, err)
+	}
+
+	// Зачислять можно на любой кошелек, владелец проверяется только у источника.
+	creditMeta := legMeta
+	creditMeta.OwnerID = ""
+
+	transfer.To, _, err = applyBalanceChange(ctx, tx, toID, OperationTransferIn, req.CreditAmount, req.CreditCurrency, nil, creditMeta)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("%s: destination wallet: %w", fn, err)
+	}
+
+	if err := postEntry(ctx, tx, transferEntry(fromID, toID, req, transfer.Fee), meta); err != nil {
+		return Transfer{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	transfer.Amount = req.Amount
+	transfer.Currency = req.Currency
+	transfer.CreditAmount = req.CreditAmount
+	transfer.CreditCurrency = req.CreditCurrency
+	transfer.Rate = req.Rate
+
+	if err := saveIdempotencyResponse(ctx, tx, meta, transfer); err != nil {
+		return Transfer{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transfer{}, fmt.Errorf("%s: failed to commit transaction: %w", fn, err)
+	}
+
+	return transfer, nil
+}
+
+// transferEntry — проводка перевода; комиссия fee списывается с отправителя
+// вместе с суммой. При конвертации деньги проходят через счет
+// fx:conversion, чтобы сумма по каждой валюте оставалась нулевой.
+func transferEntry(fromID, toID uuid.UUID, req TransferRequest, fee int64) JournalEntry {
+	entry := JournalEntry{
+		Type: OperationTransferOut,
+		Postings: []Posting{
+			{Account: WalletAccount(fromID), Amount: -req.Amount - fee, Currency: req.Currency},
+		},
+	}
+
+	if req.CreditCurrency != req.Currency || req.CreditAmount != req.Amount {
+		entry.Postings = append(entry.Postings,
+			Posting{Account: AccountFXConversion, Amount: req.Amount, Currency: req.Currency},
+			Posting{Account: AccountFXConversion, Amount: -req.CreditAmount, Currency: req.CreditCurrency},
+		)
+	}
+
+	entry.Postings = append(entry.Postings,
+		Posting{Account: WalletAccount(toID), Amount: req.CreditAmount, Currency: req.CreditCurrency},
+	)
+
+	return withFee(entry, fee, req.Currency)
+}
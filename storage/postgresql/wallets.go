@@ -0,0 +1,139 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ключи сортировки ListWallets. При равных значениях порядок уточняется
+// по wallet_id в том же направлении.
+const (
+	WalletSortID        = "wallet_id"
+	WalletSortBalance   = "balance"
+	WalletSortCreatedAt = "created_at"
+)
+
+// WalletFilter — фильтр выборки кошельков. Нулевые значения не ограничивают
+// выборку; MinBalance и MaxBalance включаются, CreatedTo — нет.
+type WalletFilter struct {
+	OwnerID     string
+	Status      string
+	Currency    string
+	MinBalance  *int64
+	MaxBalance  *int64
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	// Sort — один из WalletSort*, пусто — WalletSortID. After — курсор:
+	// выборка начинается со следующего за ним кошелька.
+	Sort  string
+	Desc  bool
+	After *WalletCursor
+	Limit int
+}
+
+// WalletCursor — позиция в выборке: последний кошелек предыдущей страницы.
+// Из значений сортировки используется только то, по которому идет Sort.
+type WalletCursor struct {
+	WalletID  uuid.UUID
+	Balance   int64
+	CreatedAt time.Time
+}
+
+// ListWallets возвращает кошельки по фильтру с постраничной выборкой по
+// ключу (значение сортировки, wallet_id): страницы не съезжают при вставке
+// новых кошельков и не требуют OFFSET.
+func (sp *StoragePostgresql) ListWallets(ctx context.Context, filter WalletFilter) (_ []Wallet, err error) {
+	const fn = "storage.postgresql.ListWallets"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	if filter.Sort == "" {
+		filter.Sort = WalletSortID
+	}
+
+	direction, cmp := "ASC", ">"
+	if filter.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	args := []any{
+		filter.OwnerID,
+		filter.Status,
+		filter.Currency,
+		nullInt64(filter.MinBalance),
+		nullInt64(filter.MaxBalance),
+		sql.NullTime{Time: filter.CreatedFrom, Valid: !filter.CreatedFrom.IsZero()},
+		sql.NullTime{Time: filter.CreatedTo, Valid: !filter.CreatedTo.IsZero()},
+	}
+
+	// Колонки подставляются в текст запроса только из этого switch.
+	var order, keyset string
+	switch filter.Sort {
+	case WalletSortID:
+		order = "wallet_id " + direction
+		if c := filter.After; c != nil {
+			args = append(args, c.WalletID)
+			keyset = fmt.Sprintf("AND wallet_id %s $%d", cmp, len(args))
+		}
+	case WalletSortBalance, WalletSortCreatedAt:
+		order = fmt.Sprintf("%s %s, wallet_id %s", filter.Sort, direction, direction)
+		if c := filter.After; c != nil {
+			var value any = c.Balance
+			if filter.Sort == WalletSortCreatedAt {
+				value = c.CreatedAt
+			}
+			args = append(args, value, c.WalletID)
+			keyset = fmt.Sprintf("AND (%s, wallet_id) %s ($%d, $%d)", filter.Sort, cmp, len(args)-1, len(args))
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported sort %q", fn, filter.Sort)
+	}
+
+	args = append(args, filter.Limit)
+
+	rows, err := sp.db.QueryContext(ctx, `
+		SELECT `+walletColumns+`
+		FROM wallets
+		WHERE ($1 = '' OR owner_id = $1)
+			AND ($2 = '' OR status = $2)
+			AND ($3 = '' OR currency = $3)
+			AND ($4::bigint IS NULL OR balance >= $4)
+			AND ($5::bigint IS NULL OR balance <= $5)
+			AND ($6::timestamptz IS NULL OR created_at >= $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
+			`+keyset+`
+		ORDER BY `+order+`
+		LIMIT $`+fmt.Sprint(len(args))+`;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query wallets: %w", fn, err)
+	}
+	defer rows.Close()
+
+	wallets := []Wallet{}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", fn, err)
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterate rows: %w", fn, err)
+	}
+
+	return wallets, nil
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
@@ -0,0 +1,348 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"wallet/storage"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryDead      = "DEAD"
+)
+
+// Webhook — подписка на события. Пустой OwnerID — события всех кошельков.
+type Webhook struct {
+	WebhookID uuid.UUID
+	URL       string
+	Secret    string
+	OwnerID   string
+	Active    bool
+	CreatedAt time.Time
+}
+
+// WebhookDelivery — доставка одного события одному вебхуку. URL, Secret,
+// EventType и Payload заполняются только для доставок, выданных
+// ClaimDeliveries.
+type WebhookDelivery struct {
+	DeliveryID     uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        uuid.UUID
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	URL       string
+	Secret    string
+	EventType string
+	Payload   json.RawMessage
+}
+
+// DeliveryFailure — результат неудачной попытки. Dead — попыток больше не
+// будет, иначе следующая попытка не раньше RetryAt.
+type DeliveryFailure struct {
+	StatusCode int
+	Error      string
+	RetryAt    time.Time
+	Dead       bool
+}
+
+const webhookColumns = "webhook_id, url, secret, COALESCE(owner_id, ''), active, created_at"
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var w Webhook
+	err := row.Scan(&w.WebhookID, &w.URL, &w.Secret, &w.OwnerID, &w.Active, &w.CreatedAt)
+	return w, err
+}
+
+const deliveryColumns = `delivery_id, webhook_id, event_id, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), delivered_at, created_at, updated_at`
+
+func scanDelivery(row rowScanner) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.DeliveryID, &d.WebhookID, &d.EventID, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+func (sp *StoragePostgresql) CreateWebhook(ctx context.Context, webhook Webhook) (_ Webhook, err error) {
+	const fn = "storage.postgresql.CreateWebhook"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	created, err := scanWebhook(sp.db.QueryRowContext(ctx, `
+		INSERT INTO webhooks (url, secret, owner_id)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING `+webhookColumns,
+		webhook.URL, webhook.Secret, webhook.OwnerID,
+	))
+	if err != nil {
+		return Webhook{}, fmt.Errorf("%s: failed to create webhook: %w", fn, err)
+	}
+
+	return created, nil
+}
+
+func (sp *StoragePostgresql) ListWebhooks(ctx context.Context) (_ []Webhook, err error) {
+	const fn = "storage.postgresql.ListWebhooks"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err)
+	defer done()
+
+	rows, err := sp.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query webhooks: %w", fn, err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan webhook: %w", fn, err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return webhooks, nil
+}
+
+// DeactivateWebhook отключает вебхук: новые события ему не раскладываются,
+// а ожидающие доставки больше не отправляются.
+func (sp *StoragePostgresql) DeactivateWebhook(ctx context.Context, webhookID uuid.UUID) (err error) {
+	const fn = "storage.postgresql.DeactivateWebhook"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, attribute.String("webhook.id", webhookID.String()))
+	defer done()
+
+	res, err := sp.db.ExecContext(ctx, "UPDATE webhooks SET active = false WHERE webhook_id = $1", webhookID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to deactivate webhook: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// ListDeliveries возвращает последние доставки вебхука, новые первыми.
+// Пустой status — все статусы.
+func (sp *StoragePostgresql) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) (_ []WebhookDelivery, err error) {
+	const fn = "storage.postgresql.ListDeliveries"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Read, &err, attribute.String("webhook.id", webhookID.String()))
+	defer done()
+
+	var exists bool
+	if err := sp.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM webhooks WHERE webhook_id = $1)", webhookID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: failed to check webhook: %w", fn, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+
+	rows, err := sp.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3;
+	`, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query deliveries: %w", fn, err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan delivery: %w", fn, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery возвращает доставку из DEAD в очередь с обнуленным счетчиком
+// попыток.
+func (sp *StoragePostgresql) RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (_ WebhookDelivery, err error) {
+	const fn = "storage.postgresql.RetryDelivery"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err, attribute.String("webhook.id", webhookID.String()))
+	defer done()
+
+	d, err := scanDelivery(sp.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = 0, next_attempt_at = now(), updated_at = now()
+		WHERE delivery_id = $1 AND webhook_id = $2 AND status = 'DEAD'
+		RETURNING `+deliveryColumns,
+		deliveryID, webhookID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WebhookDelivery{}, fmt.Errorf("%s: %w", fn, storage.ErrDeliveryNotFound)
+		}
+		return WebhookDelivery{}, fmt.Errorf("%s: failed to requeue delivery: %w", fn, err)
+	}
+
+	return d, nil
+}
+
+// FanOutEvents раскладывает до limit новых событий outbox по активным
+// вебхукам и возвращает число обработанных событий. Несколько экземпляров
+// сервиса не мешают друг другу: занятые события пропускаются.
+func (sp *StoragePostgresql) FanOutEvents(ctx context.Context, limit int) (_ int, err error) {
+	const fn = "storage.postgresql.FanOutEvents"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	res, err := sp.db.ExecContext(ctx, `
+		WITH events AS (
+			SELECT event_id, wallet_id
+			FROM wallet_events
+			WHERE dispatched_at IS NULL
+			ORDER BY seq
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), deliveries AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT h.webhook_id, e.event_id
+			FROM events e
+			JOIN wallets w ON w.wallet_id = e.wallet_id
+			JOIN webhooks h ON h.active AND (h.owner_id IS NULL OR h.owner_id = w.owner_id)
+			ON CONFLICT (webhook_id, event_id) DO NOTHING
+		)
+		UPDATE wallet_events
+		SET dispatched_at = now()
+		WHERE event_id IN (SELECT event_id FROM events);
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to fan out events: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return int(n), nil
+}
+
+// ClaimDeliveries выдает до limit доставок, у которых подошло время попытки,
+// и сразу откладывает их на lease: если экземпляр упадет, не сообщив
+// результат, доставка повторится после истечения lease.
+func (sp *StoragePostgresql) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (_ []WebhookDelivery, err error) {
+	const fn = "storage.postgresql.ClaimDeliveries"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	rows, err := sp.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT d.delivery_id
+			FROM webhook_deliveries d
+			JOIN webhooks h ON h.webhook_id = d.webhook_id AND h.active
+			WHERE d.status = 'PENDING' AND d.next_attempt_at <= now()
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = now() + $2 * interval '1 millisecond',
+			updated_at = now()
+		FROM due, webhooks h, wallet_events e
+		WHERE d.delivery_id = due.delivery_id
+			AND h.webhook_id = d.webhook_id
+			AND e.event_id = d.event_id
+		RETURNING d.delivery_id, d.webhook_id, d.event_id, d.attempts, h.url, h.secret, e.event_type, e.payload;
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to claim deliveries: %w", fn, err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		d := WebhookDelivery{Status: DeliveryPending}
+		if err := rows.Scan(&d.DeliveryID, &d.WebhookID, &d.EventID, &d.Attempts, &d.URL, &d.Secret, &d.EventType, &d.Payload); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan delivery: %w", fn, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return deliveries, nil
+}
+
+func (sp *StoragePostgresql) MarkDelivered(ctx context.Context, deliveryID uuid.UUID, statusCode int) (err error) {
+	const fn = "storage.postgresql.MarkDelivered"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	_, err = sp.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'DELIVERED', last_status_code = $1, last_error = NULL, delivered_at = now(), updated_at = now()
+		WHERE delivery_id = $2;
+	`, statusCode, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update delivery: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (sp *StoragePostgresql) MarkFailed(ctx context.Context, deliveryID uuid.UUID, failure DeliveryFailure) (err error) {
+	const fn = "storage.postgresql.MarkFailed"
+
+	ctx, done := startOp(ctx, fn, sp.timeouts.Write, &err)
+	defer done()
+
+	status := DeliveryPending
+	if failure.Dead {
+		status = DeliveryDead
+	}
+
+	_, err = sp.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, last_status_code = NULLIF($2, 0), last_error = $3, next_attempt_at = $4, updated_at = now()
+		WHERE delivery_id = $5;
+	`, status, failure.StatusCode, failure.Error, failure.RetryAt, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update delivery: %w", fn, err)
+	}
+
+	return nil
+}
@@ -12,5 +12,27 @@ var (
 var (
 	ErrWalletNotFound = errors.New("wallet not found")
 	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrWalletExists = errors.New("wallet already exists")
+	ErrIdempotencyConflict = errors.New("idempotency key reused with different request")
+	ErrCurrencyMismatch = errors.New("currency does not match wallet currency")
+	ErrHoldNotFound = errors.New("hold not found")
+	ErrHoldNotActive = errors.New("hold is not active")
+	ErrHoldExpired = errors.New("hold has expired")
+	ErrHoldAmountExceeded = errors.New("capture amount exceeds hold amount")
+	ErrForbidden = errors.New("access to wallet denied")
+	ErrClientNotFound = errors.New("api client not found")
+	ErrClientExists = errors.New("api client already exists")
+	ErrCanceled = errors.New("operation canceled")
+	ErrTimeout = errors.New("operation timed out")
+	ErrWalletFrozen = errors.New("wallet is frozen")
+	ErrWalletClosed = errors.New("wallet is closed")
+	ErrInvalidStatusTransition = errors.New("invalid wallet status transition")
+	ErrWalletNotEmpty = errors.New("wallet balance is not zero")
+	ErrLimitExceeded = errors.New("wallet limit exceeded")
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrStatementTooLarge = errors.New("too many operations in statement period")
+	ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+	ErrFeeExceedsAmount = errors.New("fee exceeds operation amount")
 
 )
\ No newline at end of file